@@ -0,0 +1,718 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Chart outcomes reported in the bump summary.
+const (
+	bumpStatusOK      = "ok"
+	bumpStatusSkipped = "skipped"
+	bumpStatusWarning = "warning"
+	bumpStatusFailed  = "failed"
+)
+
+// bumpChart is one chart that depends on the opentelemetry-collector chart.
+type bumpChart struct {
+	Name string
+	// Dir holds Chart.yaml and values.yaml; the changelog is
+	// <Name>/CHANGELOG.md.
+	Dir string
+	// Entry returns the chart's own changelog entry for a bump to version.
+	Entry func(version string) helmlog.Entry
+	// MakeTarget regenerates the rendered collector config after the
+	// dependency update. Empty means the chart has golden renders instead.
+	MakeTarget string
+}
+
+var collectorBumpCharts = []bumpChart{
+	{Name: "otel-ecs-ec2", Dir: "otel-ecs-ec2", Entry: ecsBumpEntry, MakeTarget: "all"},
+	{Name: "otel-integration", Dir: "otel-integration/k8s-helm", Entry: collectorBumpEntry},
+	{Name: "otel-linux-standalone", Dir: "otel-linux-standalone", Entry: collectorBumpEntry, MakeTarget: "otel-config"},
+	{Name: "otel-macos-standalone", Dir: "otel-macos-standalone", Entry: collectorBumpEntry, MakeTarget: "otel-config"},
+	{Name: "otel-windows-standalone", Dir: "otel-windows-standalone", Entry: collectorBumpEntry, MakeTarget: "otel-config"},
+}
+
+func collectorBumpEntry(version string) helmlog.Entry {
+	dependency := helmlog.ChartDependency{Name: collectorDependencyName, Version: version}
+	return helmlog.Entry{Tag: "Chore", Text: dependencyBumpPhrase(dependency), Origin: helmlog.OriginChart}
+}
+
+func ecsBumpEntry(version string) helmlog.Entry {
+	return helmlog.Entry{Tag: "Change", Text: "Update Helm dependency `opentelemetry-agent` to chart version `" + version + "`.", Origin: helmlog.OriginChart}
+}
+
+type bumpCollectorOptions struct {
+	parseOptions
+	Version       string
+	ChangelogFile string
+	SourceCommit  string
+	SourcePR      string
+	SourceRepo    string
+	Skip          []string
+	SummaryFile   string
+	DryRun        bool
+	PostCommands  bool
+	RepoRoot      string
+}
+
+// bumpResult is the outcome of bumping one chart. Note is shown next to the
+// status in the summary table; Warnings get their own section.
+type bumpResult struct {
+	Chart           string
+	Status          string
+	ChartVersion    string
+	NewChartVersion string
+	Note            string
+	Warnings        []string
+}
+
+// fileEdit is the planned content of one file, kept in memory until every
+// edit of the chart has been validated.
+type fileEdit struct {
+	Path     string
+	Original []byte
+	Updated  []byte
+}
+
+func newBumpCollectorCmd() *cobra.Command {
+	opts := bumpCollectorOptions{SourceRepo: "coralogix/opentelemetry-helm-charts", PostCommands: true}
+
+	cmd := &cobra.Command{
+		Use:   "bump-collector --version X.Y.Z",
+		Short: "Bump the opentelemetry-collector chart dependency across the integration charts",
+		Long: `Bump the opentelemetry-collector chart dependency of every integration chart.
+
+For each chart the patch version in Chart.yaml and global.version in values.yaml
+are incremented, the dependency version is set, and a release is added to the
+changelog. The release is dated --today, $SOURCE_DATE_EPOCH or the current day
+in --timezone. --changelog-file adds the "#### Changes from
+opentelemetry-collector" blocks to that release. YAML files are edited in place,
+so comments, quoting and key order are kept, and every edit is checked with the
+changelog parser before anything is written.
+
+After the edits, "helm dependency update" and the chart's config generation are
+run unless --post-commands=false. With --dry-run nothing is written and a
+unified diff of the edits is printed instead. A Markdown summary is written to
+--summary-file, or printed when it is not set.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runBumpCollector(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Version, "version", "", "New opentelemetry-collector chart version")
+	cmd.Flags().StringVar(&opts.ChangelogFile, "changelog-file", "", `File with "#### Changes from opentelemetry-collector X.Y.Z:" blocks to add to each release`)
+	cmd.Flags().StringVar(&opts.SourceCommit, "source-commit", "", "Source commit SHA for the summary")
+	cmd.Flags().StringVar(&opts.SourcePR, "source-pr", "", "Source pull request number for the summary")
+	cmd.Flags().StringVar(&opts.SourceRepo, "source-repo", opts.SourceRepo, "Repository of the source commit and pull request")
+	cmd.Flags().StringArrayVar(&opts.Skip, "skip", nil, "Chart to leave untouched (can be repeated)")
+	cmd.Flags().StringVar(&opts.SummaryFile, "summary-file", "", "Write the Markdown summary to this file instead of stdout")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print a unified diff of the edits without writing them")
+	cmd.Flags().BoolVar(&opts.PostCommands, "post-commands", opts.PostCommands, "Run helm dependency update and config generation after editing")
+	addParseFlags(cmd, &opts.parseOptions)
+	_ = cmd.MarkFlagRequired("version")
+
+	return cmd
+}
+
+func runBumpCollector(opts bumpCollectorOptions) error {
+	opts.Version = trimVersionPrefix(opts.Version)
+	if !versionPattern.MatchString(opts.Version) {
+		return fmt.Errorf("ERROR: invalid version %q", opts.Version)
+	}
+
+	skip := map[string]bool{}
+	for _, name := range opts.Skip {
+		if !isCollectorBumpChart(name) {
+			return fmt.Errorf("ERROR: unknown chart %q for --skip (expected one of %s)", name, strings.Join(collectorBumpChartNames(), ", "))
+		}
+		skip[name] = true
+	}
+
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		root, err := gitRepoRoot()
+		if err != nil {
+			return err
+		}
+		repoRoot = root
+	}
+
+	upstream := helmlog.Release{}
+	if opts.ChangelogFile != "" {
+		release, err := parseUpstreamChangelogFile(opts.ChangelogFile)
+		if err != nil {
+			return err
+		}
+		upstream = release
+	}
+
+	location, err := opts.location()
+	if err != nil {
+		return err
+	}
+	reference, err := opts.referenceTime(location)
+	if err != nil {
+		return err
+	}
+	today := reference.In(location).Format(time.DateOnly)
+	results := make([]bumpResult, 0, len(collectorBumpCharts))
+	for _, chart := range collectorBumpCharts {
+		if skip[chart.Name] {
+			results = append(results, bumpResult{Chart: chart.Name, Status: bumpStatusSkipped, Note: "skipped with --skip"})
+			continue
+		}
+
+		result, edits, err := planChartBump(repoRoot, chart, opts, upstream, today)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			results = append(results, bumpResult{Chart: chart.Name, Status: bumpStatusFailed, Note: firstErrorLine(err)})
+			continue
+		}
+		if result.Status == bumpStatusSkipped {
+			results = append(results, result)
+			continue
+		}
+
+		if opts.DryRun {
+			for _, edit := range edits {
+				diff, err := unifiedDiff(edit.Path, edit.Original, edit.Updated)
+				if err != nil {
+					return err
+				}
+				fmt.Print(diff)
+			}
+			results = append(results, result)
+			continue
+		}
+
+		if err := writeFileEdits(repoRoot, edits); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			result.Status = bumpStatusFailed
+			result.Note = firstErrorLine(err)
+			results = append(results, result)
+			continue
+		}
+
+		if opts.PostCommands {
+			if warnings := runBumpPostCommands(repoRoot, chart); len(warnings) > 0 {
+				result.Status = bumpStatusWarning
+				result.Warnings = warnings
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	summary, err := renderBumpSummary(results, opts)
+	if err != nil {
+		return err
+	}
+	if opts.SummaryFile == "" {
+		fmt.Print(summary)
+	} else {
+		if err := os.WriteFile(opts.SummaryFile, []byte(summary), 0o644); err != nil {
+			return fmt.Errorf("ERROR: failed to write %s: %w", opts.SummaryFile, err)
+		}
+		fmt.Printf("Wrote %s\n", opts.SummaryFile)
+	}
+
+	failed := []string{}
+	for _, result := range results {
+		if result.Status == bumpStatusFailed {
+			failed = append(failed, result.Chart)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("ERROR: failed to bump %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// firstErrorLine keeps summary table cells on one line; parse errors span
+// several.
+func firstErrorLine(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return strings.TrimPrefix(line, "ERROR: ")
+}
+
+func isCollectorBumpChart(name string) bool {
+	for _, chart := range collectorBumpCharts {
+		if chart.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func collectorBumpChartNames() []string {
+	names := make([]string, 0, len(collectorBumpCharts))
+	for _, chart := range collectorBumpCharts {
+		names = append(names, chart.Name)
+	}
+
+	return names
+}
+
+// parseUpstreamChangelogFile reads the "#### Changes from ..." blocks the sync
+// workflow extracts from the collector chart changelog. The blocks are parsed
+// under a placeholder release header so they go through the same checks as
+// the changelogs they are copied into.
+func parseUpstreamChangelogFile(path string) (helmlog.Release, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return helmlog.Release{}, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+	}
+
+	wrapped := "### v0.0.0 / 1970-01-01\n" + string(content)
+	result, diagnostics, err := parseMarkdownDiagnostics(strings.NewReader(wrapped), parseOptions{Dialect: helmlog.DialectHelm})
+	if err != nil {
+		return helmlog.Release{}, err
+	}
+	if len(diagnostics) > 0 {
+		diagnostic := diagnostics[0]
+		diagnostic.Path = path
+		diagnostic.ReleaseVersion = ""
+		diagnostic.LineNumber--
+		return helmlog.Release{}, diagnosticError(diagnostic)
+	}
+
+	return result.Log.Releases[0], nil
+}
+
+// planChartBump computes the Chart.yaml, values.yaml and CHANGELOG.md edits
+// for one chart and validates them. Nothing is written.
+func planChartBump(repoRoot string, chart bumpChart, opts bumpCollectorOptions, upstream helmlog.Release, today string) (bumpResult, []fileEdit, error) {
+	result := bumpResult{Chart: chart.Name, Status: bumpStatusOK}
+
+	chartPath := filepath.Join(chart.Dir, "Chart.yaml")
+	chartContent, err := os.ReadFile(filepath.Join(repoRoot, chartPath))
+	if err != nil {
+		return result, nil, fmt.Errorf("ERROR: failed to read %s: %w", chartPath, err)
+	}
+
+	current, err := helmlog.ParseChart(chartContent)
+	if err != nil {
+		return result, nil, fmt.Errorf("ERROR: %s: %w", chartPath, err)
+	}
+	dependency, ok := current.Dependency(collectorDependencyName)
+	if !ok {
+		return result, nil, fmt.Errorf("ERROR: %s has no %s dependency", chartPath, collectorDependencyName)
+	}
+	if trimVersionPrefix(dependency.Version) == opts.Version {
+		result.Status = bumpStatusSkipped
+		result.Note = "already at " + opts.Version
+		return result, nil, nil
+	}
+
+	version, ok := helmlog.ParseVersion(current.Version)
+	if !ok {
+		return result, nil, fmt.Errorf("ERROR: %s has invalid chart version %q", chartPath, current.Version)
+	}
+	result.ChartVersion = current.Version
+	result.NewChartVersion = fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Patch+1)
+	result.Note = fmt.Sprintf("%s → %s", result.ChartVersion, result.NewChartVersion)
+
+	updatedChart, err := bumpChartYAML(chartContent, result.NewChartVersion, opts.Version)
+	if err != nil {
+		return result, nil, fmt.Errorf("ERROR: %s: %w", chartPath, err)
+	}
+	edits := []fileEdit{{Path: chartPath, Original: chartContent, Updated: updatedChart}}
+
+	valuesPath := filepath.Join(chart.Dir, "values.yaml")
+	valuesContent, err := os.ReadFile(filepath.Join(repoRoot, valuesPath))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return result, nil, fmt.Errorf("ERROR: failed to read %s: %w", valuesPath, err)
+	default:
+		updatedValues, changed, err := bumpValuesYAML(valuesContent, result.NewChartVersion)
+		if err != nil {
+			return result, nil, fmt.Errorf("ERROR: %s: %w", valuesPath, err)
+		}
+		if changed {
+			edits = append(edits, fileEdit{Path: valuesPath, Original: valuesContent, Updated: updatedValues})
+		}
+	}
+
+	changelogPath := filepath.Join(chart.Name, changelogFileName)
+	changelogContent, err := os.ReadFile(filepath.Join(repoRoot, changelogPath))
+	if err != nil {
+		return result, nil, fmt.Errorf("ERROR: failed to read %s: %w", changelogPath, err)
+	}
+
+	release := helmlog.Release{
+		Version:  "v" + result.NewChartVersion,
+		Date:     today,
+		Entries:  append([]helmlog.Entry{chart.Entry(opts.Version)}, upstream.Entries...),
+		Upstream: upstream.Upstream,
+	}
+	updatedChangelog, err := insertChangelogRelease(changelogContent, release, filepath.Join(repoRoot, changelogPath), opts.parseOptions)
+	if err != nil {
+		return result, nil, fmt.Errorf("%w (%s)", err, changelogPath)
+	}
+	edits = append(edits, fileEdit{Path: changelogPath, Original: changelogContent, Updated: updatedChangelog})
+
+	return result, edits, nil
+}
+
+// bumpChartYAML sets the chart version and the version of every
+// opentelemetry-collector dependency, aliases included.
+func bumpChartYAML(content []byte, chartVersion, dependencyVersion string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid Chart.yaml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.New("empty Chart.yaml")
+	}
+	root := doc.Content[0]
+
+	versionNode := yamlMappingValue(root, "version")
+	if versionNode == nil {
+		return nil, errors.New("no chart version")
+	}
+	edits := []yamlScalarEdit{{Node: versionNode, Value: chartVersion}}
+
+	if dependencies := yamlMappingValue(root, "dependencies"); dependencies != nil && dependencies.Kind == yaml.SequenceNode {
+		for _, dependency := range dependencies.Content {
+			name := yamlMappingValue(dependency, "name")
+			if name == nil || name.Value != collectorDependencyName {
+				continue
+			}
+			if node := yamlMappingValue(dependency, "version"); node != nil {
+				edits = append(edits, yamlScalarEdit{Node: node, Value: dependencyVersion})
+			}
+		}
+	}
+
+	updated, err := applyYAMLEdits(content, edits)
+	if err != nil {
+		return nil, err
+	}
+
+	chart, err := helmlog.ParseChart(updated)
+	if err != nil {
+		return nil, err
+	}
+	if chart.Version != chartVersion {
+		return nil, fmt.Errorf("chart version is %q after the edit, want %q", chart.Version, chartVersion)
+	}
+	for _, dependency := range chart.Dependencies {
+		if dependency.Name == collectorDependencyName && dependency.Version != dependencyVersion {
+			return nil, fmt.Errorf("%s version is %q after the edit, want %q", collectorDependencyName, dependency.Version, dependencyVersion)
+		}
+	}
+
+	return updated, nil
+}
+
+// bumpValuesYAML sets global.version, which the standalone charts use to
+// report their own version. Charts without it are left alone.
+func bumpValuesYAML(content []byte, chartVersion string) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, false, fmt.Errorf("invalid values.yaml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return content, false, nil
+	}
+
+	versionNode := yamlMappingValue(yamlMappingValue(doc.Content[0], "global"), "version")
+	if versionNode == nil || versionNode.Value == "" {
+		return content, false, nil
+	}
+
+	updated, err := applyYAMLEdits(content, []yamlScalarEdit{{Node: versionNode, Value: chartVersion}})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var values struct {
+		Global struct {
+			Version string `yaml:"version"`
+		} `yaml:"global"`
+	}
+	if err := yaml.Unmarshal(updated, &values); err != nil {
+		return nil, false, fmt.Errorf("invalid values.yaml after the edit: %w", err)
+	}
+	if values.Global.Version != chartVersion {
+		return nil, false, fmt.Errorf("global.version is %q after the edit, want %q", values.Global.Version, chartVersion)
+	}
+
+	return updated, true, nil
+}
+
+// yamlScalarEdit replaces the value of one scalar node.
+type yamlScalarEdit struct {
+	Node  *yaml.Node
+	Value string
+}
+
+// applyYAMLEdits rewrites scalars where they stand in the source, so comments,
+// key order and the original quoting style are kept. Only plain and quoted
+// scalars without escapes are supported, which covers version strings.
+func applyYAMLEdits(content []byte, edits []yamlScalarEdit) ([]byte, error) {
+	lineStarts := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	type replacement struct {
+		offset int
+		old    string
+		new    string
+	}
+	replacements := make([]replacement, 0, len(edits))
+	for _, edit := range edits {
+		node := edit.Node
+		if node.Kind != yaml.ScalarNode || node.Line < 1 || node.Line > len(lineStarts) {
+			return nil, fmt.Errorf("cannot edit value at line %d in place", node.Line)
+		}
+
+		quote := ""
+		switch node.Style {
+		case yaml.DoubleQuotedStyle:
+			quote = `"`
+		case yaml.SingleQuotedStyle:
+			quote = "'"
+		case 0:
+		default:
+			return nil, fmt.Errorf("cannot edit value at line %d in place", node.Line)
+		}
+
+		line := content[lineStarts[node.Line-1]:]
+		if end := bytes.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+		}
+		runes := []rune(string(line))
+		if node.Column < 1 || node.Column > len(runes) {
+			return nil, fmt.Errorf("cannot edit value at line %d in place", node.Line)
+		}
+
+		offset := lineStarts[node.Line-1] + len(string(runes[:node.Column-1]))
+		old := quote + node.Value + quote
+		if !bytes.HasPrefix(content[offset:], []byte(old)) {
+			return nil, fmt.Errorf("cannot edit value %q at line %d in place", node.Value, node.Line)
+		}
+		replacements = append(replacements, replacement{offset: offset, old: old, new: quote + edit.Value + quote})
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].offset > replacements[j].offset
+	})
+
+	updated := append([]byte{}, content...)
+	for _, r := range replacements {
+		updated = append(updated[:r.offset], append([]byte(r.new), updated[r.offset+len(r.old):]...)...)
+	}
+
+	return updated, nil
+}
+
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// insertChangelogRelease renders release in the changelog's dialect, inserts
+// it before the newest release and checks the result with the parser.
+func insertChangelogRelease(content []byte, release helmlog.Release, path string, opts parseOptions) ([]byte, error) {
+	opts, err := opts.withConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	dialect, err := resolveDialect(opts.dialectName(), lines)
+	if err != nil {
+		return nil, err
+	}
+
+	section := strings.Split(strings.TrimSuffix(renderChangelogRelease(release, dialect), "\n"), "\n")
+
+	at := -1
+	for i, line := range lines {
+		if dialect.ReleaseHeader.MatchString(strings.TrimSpace(line)) {
+			at = i
+			break
+		}
+	}
+
+	var updated []string
+	if at < 0 {
+		updated = append(strings.Split(strings.TrimRight(strings.Join(lines, "\n"), "\n"), "\n"), "")
+		updated = append(updated, section...)
+	} else {
+		updated = append(append(append([]string{}, lines[:at]...), section...), lines[at:]...)
+	}
+	output := []byte(strings.TrimRight(strings.Join(updated, "\n"), "\n") + "\n")
+
+	result, diagnostics, err := parseMarkdownDiagnostics(bytes.NewReader(output), opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnosticError(diagnostics[0])
+	}
+	helmlog.Sort(&result.Log)
+	if newest := result.Log.Releases[0]; newest.Version != release.Version {
+		return nil, fmt.Errorf("ERROR: newest release is %s after the edit, want %s", newest.Version, release.Version)
+	}
+
+	return output, nil
+}
+
+// renderChangelogRelease writes a release the way the chart changelogs lay it
+// out: the chart's own entries, then one block per upstream release. The
+// result ends with a blank line so it can be placed before the next release.
+func renderChangelogRelease(release helmlog.Release, dialect *helmlog.Dialect) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, dialect.HeaderTemplate+"\n\n", release.Version, release.Date)
+	for _, entry := range localEntries(release) {
+		b.WriteString(markdownBullet(entry) + "\n")
+	}
+	for _, upstream := range release.Upstream {
+		fmt.Fprintf(&b, "\n#### Changes from %s %s:\n", upstream.Chart, upstream.Version)
+		for _, entry := range upstream.Entries {
+			b.WriteString(markdownBullet(entry) + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func writeFileEdits(repoRoot string, edits []fileEdit) error {
+	for _, edit := range edits {
+		if err := os.WriteFile(filepath.Join(repoRoot, edit.Path), edit.Updated, 0o644); err != nil {
+			return fmt.Errorf("ERROR: failed to write %s: %w", edit.Path, err)
+		}
+		fmt.Printf("Updated %s\n", edit.Path)
+	}
+
+	return nil
+}
+
+// runBumpPostCommands refreshes the dependency archives and the generated
+// files of a chart. Failures are returned as warnings: the edits are already
+// written and the summary asks for a manual look.
+func runBumpPostCommands(repoRoot string, chart bumpChart) []string {
+	commands := [][]string{{"helm", "dependency", "update"}}
+	dirs := []string{chart.Dir}
+	if chart.MakeTarget == "" {
+		commands = append(commands, []string{".github/scripts/check-helm-golden-renders.sh", "--update"})
+		dirs = append(dirs, ".")
+	} else if _, err := os.Stat(filepath.Join(repoRoot, chart.Dir, "Makefile")); err == nil {
+		commands = append(commands, []string{"make", chart.MakeTarget})
+		dirs = append(dirs, chart.Dir)
+	}
+
+	warnings := []string{}
+	for i, args := range commands {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = filepath.Join(repoRoot, dirs[i])
+		output, err := cmd.CombinedOutput()
+		// make can exit 0 after helm template printed an error.
+		if err != nil || makeErrorPattern.Match(output) {
+			os.Stderr.Write(output)
+			warnings = append(warnings, strings.Join(args, " ")+" failed")
+		}
+	}
+
+	return warnings
+}
+
+var makeErrorPattern = regexp.MustCompile(`(?im)^error:`)
+
+var bumpStatusLabels = map[string]string{
+	bumpStatusOK:      "✅ OK",
+	bumpStatusSkipped: "⏭️ Skipped",
+	bumpStatusWarning: "⚠️ Warning",
+	bumpStatusFailed:  "❌ Failed",
+}
+
+func renderBumpSummary(results []bumpResult, opts bumpCollectorOptions) (string, error) {
+	var b strings.Builder
+
+	b.WriteString("## OpenTelemetry Collector Bump Summary\n\n")
+	fmt.Fprintf(&b, "**Version:** `%s`\n", opts.Version)
+
+	links := []string{}
+	if opts.SourceCommit != "" {
+		short := opts.SourceCommit
+		if len(short) > 7 {
+			short = short[:7]
+		}
+		links = append(links, fmt.Sprintf("[%s](https://github.com/%s/commit/%s)", short, opts.SourceRepo, opts.SourceCommit))
+	}
+	if opts.SourcePR != "" {
+		links = append(links, fmt.Sprintf("[PR #%s](https://github.com/%s/pull/%s)", opts.SourcePR, opts.SourceRepo, opts.SourcePR))
+	}
+	if len(links) > 0 {
+		b.WriteString("**Source:** " + strings.Join(links, " | ") + "\n")
+	}
+
+	b.WriteString("\n### Charts\n\n")
+	b.WriteString("| Chart | Status | Notes |\n")
+	b.WriteString("|-------|--------|-------|\n")
+	for _, result := range results {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", result.Chart, bumpStatusLabels[result.Status], result.Note)
+	}
+
+	warnings := []string{}
+	for _, result := range results {
+		for _, warning := range result.Warnings {
+			warnings = append(warnings, fmt.Sprintf("- **%s:** %s", result.Chart, warning))
+		}
+	}
+	if len(warnings) > 0 {
+		b.WriteString("\n### Warnings\n\n")
+		b.WriteString(strings.Join(warnings, "\n") + "\n")
+	}
+
+	if opts.ChangelogFile != "" {
+		content, err := os.ReadFile(opts.ChangelogFile)
+		if err != nil {
+			return "", fmt.Errorf("ERROR: failed to read %s: %w", opts.ChangelogFile, err)
+		}
+		b.WriteString("\n### Changelog\n\n")
+		b.WriteString(strings.TrimRight(string(content), "\n") + "\n")
+	}
+
+	b.WriteString("\n---\n")
+	if opts.DryRun {
+		b.WriteString("*🔍 DRY RUN - no changes were made*\n")
+	} else {
+		b.WriteString("*Generated by helmlog bump-collector*\n")
+	}
+
+	return b.String(), nil
+}
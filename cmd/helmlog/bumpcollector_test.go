@@ -0,0 +1,242 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBumpChartYAMLKeepsLayout(t *testing.T) {
+	input := `apiVersion: v2
+name: otel-integration
+# Bumped by helmlog bump-collector.
+version: 0.0.331
+dependencies:
+  - name: opentelemetry-collector
+    alias: opentelemetry-agent
+    version: "0.135.4" # keep in sync with the other aliases
+  - name: opentelemetry-operator
+    version: "0.119.0"
+  - name: opentelemetry-collector
+    alias: opentelemetry-cluster-collector
+    version: '0.135.4'
+`
+
+	got, err := bumpChartYAML([]byte(input), "0.0.332", "0.136.0")
+	if err != nil {
+		t.Fatalf("bumpChartYAML() error = %v", err)
+	}
+
+	want := `apiVersion: v2
+name: otel-integration
+# Bumped by helmlog bump-collector.
+version: 0.0.332
+dependencies:
+  - name: opentelemetry-collector
+    alias: opentelemetry-agent
+    version: "0.136.0" # keep in sync with the other aliases
+  - name: opentelemetry-operator
+    version: "0.119.0"
+  - name: opentelemetry-collector
+    alias: opentelemetry-cluster-collector
+    version: '0.136.0'
+`
+	if string(got) != want {
+		t.Fatalf("bumpChartYAML() = %q, want %q", got, want)
+	}
+}
+
+func TestBumpValuesYAMLOnlyTouchesGlobalVersion(t *testing.T) {
+	input := `global:
+  domain: "coralogix.com"
+  version: "0.0.49"
+
+opentelemetry-agent:
+  image:
+    version: "0.0.49"
+`
+
+	got, changed, err := bumpValuesYAML([]byte(input), "0.0.50")
+	if err != nil {
+		t.Fatalf("bumpValuesYAML() error = %v", err)
+	}
+	if want := strings.Replace(input, `version: "0.0.49"`, `version: "0.0.50"`, 1); !changed || string(got) != want {
+		t.Fatalf("bumpValuesYAML() = %q, %t, want %q", got, changed, want)
+	}
+
+	_, changed, err = bumpValuesYAML([]byte("global:\n  domain: \"\"\n"), "0.0.50")
+	if err != nil || changed {
+		t.Fatalf("bumpValuesYAML() without global.version = %t, %v, want unchanged", changed, err)
+	}
+}
+
+func TestRunBumpCollector(t *testing.T) {
+	repoRoot := setupBumpCollectorTestRepo(t)
+	dir := t.TempDir()
+	entriesPath := filepath.Join(dir, "entries.md")
+	summaryPath := filepath.Join(dir, "summary.md")
+	writeTestFile(t, entriesPath, `#### Changes from opentelemetry-collector 0.101.0:
+- [:warning: Change][Feat] Enable batching by default
+  - Set batch.enabled to false to opt out
+- [Breaking][Fix] Remove the deprecated logging exporter
+`)
+
+	originalNow := nowFunc
+	t.Cleanup(func() {
+		nowFunc = originalNow
+	})
+	// 23:30 UTC on the 10th is already the 11th in Tokyo.
+	nowFunc = func() time.Time { return time.Date(2026, 2, 10, 23, 30, 0, 0, time.UTC) }
+
+	// The macOS chart is already at the target version.
+	writeTestChartAt(t, repoRoot, filepath.Join("otel-macos-standalone", "Chart.yaml"), "0.0.2", "0.101.0")
+
+	err := runBumpCollector(bumpCollectorOptions{
+		parseOptions:  parseOptions{Timezone: "Asia/Tokyo"},
+		Version:       "0.101.0",
+		ChangelogFile: entriesPath,
+		Skip:          []string{"otel-windows-standalone"},
+		SummaryFile:   summaryPath,
+		RepoRoot:      repoRoot,
+	})
+	if err != nil {
+		t.Fatalf("runBumpCollector() error = %v", err)
+	}
+
+	chart, err := os.ReadFile(filepath.Join(repoRoot, "otel-linux-standalone", "Chart.yaml"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(chart), "version: 0.0.2\n") || !strings.Contains(string(chart), `version: "0.101.0"`) {
+		t.Fatalf("Chart.yaml = %s", chart)
+	}
+
+	values, err := os.ReadFile(filepath.Join(repoRoot, "otel-linux-standalone", "values.yaml"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if string(values) != "global:\n  version: \"0.0.2\" # chart version\n" {
+		t.Fatalf("values.yaml = %q", values)
+	}
+
+	changelog, err := os.ReadFile(filepath.Join(repoRoot, "otel-linux-standalone", "CHANGELOG.md"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	wantChangelog := `# Changelog
+
+## otel-linux-standalone
+
+### v0.0.2 / 2026-02-11
+
+- [Chore] Bump chart dependency to opentelemetry-collector 0.101.0
+
+#### Changes from opentelemetry-collector 0.101.0:
+- [:warning: Change][Feat] Enable batching by default
+  - Set batch.enabled to false to opt out
+- [Breaking][Fix] Remove the deprecated logging exporter
+
+### v0.0.1 / 2026-01-01
+
+- [Feat] Initial release
+`
+	if string(changelog) != wantChangelog {
+		t.Fatalf("CHANGELOG.md = %q, want %q", changelog, wantChangelog)
+	}
+
+	ecsChangelog, err := os.ReadFile(filepath.Join(repoRoot, "otel-ecs-ec2", "CHANGELOG.md"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(ecsChangelog), "- [Change] Update Helm dependency `opentelemetry-agent` to chart version `0.101.0`.") {
+		t.Fatalf("otel-ecs-ec2 CHANGELOG.md = %s", ecsChangelog)
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	for _, want := range []string{
+		"**Version:** `0.101.0`",
+		"| otel-integration | ✅ OK | 0.0.1 → 0.0.2 |",
+		"| otel-macos-standalone | ⏭️ Skipped | already at 0.101.0 |",
+		"| otel-windows-standalone | ⏭️ Skipped | skipped with --skip |",
+		"### Changelog\n\n#### Changes from opentelemetry-collector 0.101.0:",
+		"*Generated by helmlog bump-collector*",
+	} {
+		if !strings.Contains(string(summary), want) {
+			t.Fatalf("summary missing %q:\n%s", want, summary)
+		}
+	}
+
+	windows, err := os.ReadFile(filepath.Join(repoRoot, "otel-windows-standalone", "Chart.yaml"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(windows), "version: 0.0.1\n") {
+		t.Fatalf("skipped chart was edited: %s", windows)
+	}
+}
+
+func TestRunBumpCollectorDryRunWritesNothing(t *testing.T) {
+	repoRoot := setupBumpCollectorTestRepo(t)
+	chartPath := filepath.Join(repoRoot, "otel-integration", "k8s-helm", "Chart.yaml")
+	before, err := os.ReadFile(chartPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	if err := runBumpCollector(bumpCollectorOptions{Version: "0.101.0", DryRun: true, SummaryFile: summaryPath, RepoRoot: repoRoot}); err != nil {
+		t.Fatalf("runBumpCollector() error = %v", err)
+	}
+
+	after, err := os.ReadFile(chartPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if string(after) != string(before) {
+		t.Fatalf("Chart.yaml changed during a dry run:\n%s", after)
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(summary), "DRY RUN") {
+		t.Fatalf("summary = %s, want dry run footer", summary)
+	}
+}
+
+func TestRunBumpCollectorRejectsInvalidInput(t *testing.T) {
+	repoRoot := setupBumpCollectorTestRepo(t)
+
+	err := runBumpCollector(bumpCollectorOptions{Version: "0.101.0", Skip: []string{"otel-unknown"}, RepoRoot: repoRoot})
+	if err == nil || !strings.Contains(err.Error(), `unknown chart "otel-unknown"`) {
+		t.Fatalf("runBumpCollector() error = %v, want unknown chart error", err)
+	}
+
+	entriesPath := filepath.Join(t.TempDir(), "entries.md")
+	writeTestFile(t, entriesPath, "#### Changes from opentelemetry-collector 0.101.0:\n- [Unknown] Not a tag\n")
+	err = runBumpCollector(bumpCollectorOptions{Version: "0.101.0", ChangelogFile: entriesPath, RepoRoot: repoRoot})
+	if err == nil || !strings.Contains(err.Error(), entriesPath+":2:") {
+		t.Fatalf("runBumpCollector() error = %v, want parse error in the changelog file", err)
+	}
+}
+
+// setupBumpCollectorTestRepo lays out every chart of collectorBumpCharts at
+// version 0.0.1 with opentelemetry-collector 0.100.0.
+func setupBumpCollectorTestRepo(t *testing.T) string {
+	t.Helper()
+
+	repoRoot := t.TempDir()
+	for _, chart := range collectorBumpCharts {
+		writeTestChartAt(t, repoRoot, filepath.Join(chart.Dir, "Chart.yaml"), "0.0.1", "0.100.0")
+		writeTestFile(t, filepath.Join(repoRoot, chart.Dir, "values.yaml"), "global:\n  version: \"0.0.1\" # chart version\n")
+		writeTestFile(t, filepath.Join(repoRoot, chart.Name, "CHANGELOG.md"), "# Changelog\n\n## "+chart.Name+"\n\n### v0.0.1 / 2026-01-01\n\n- [Feat] Initial release\n")
+	}
+
+	return repoRoot
+}
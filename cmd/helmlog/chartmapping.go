@@ -0,0 +1,413 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+type chartMappingResult struct {
+	Mappings []chartMappingEntry `json:"mappings"`
+}
+
+// chartMappingEntry maps one chart version to the dependencies it shipped
+// with. CollectorChartVersion is the version of the tracked dependency and is
+// kept for consumers that predate the Dependencies list. The dependencies are
+// the newest ones of the chart version and Commit is the oldest commit with
+// this chart version and these dependencies, the one that introduced them.
+// CollectorChanged reports whether the tracked dependency differs from the
+// chart version before it.
+type chartMappingEntry struct {
+	ChartVersion          string                   `json:"chart_version"`
+	CollectorChartVersion string                   `json:"collector_chart_version"`
+	CollectorAppVersion   string                   `json:"collector_app_version,omitempty"`
+	CollectorChanged      bool                     `json:"collector_changed"`
+	ReleaseDate           string                   `json:"release_date,omitempty"`
+	Commit                string                   `json:"commit,omitempty"`
+	CommitTimestamp       string                   `json:"commit_timestamp,omitempty"`
+	Dependencies          []chartMappingDependency `json:"dependencies,omitempty"`
+}
+
+type chartMappingDependency struct {
+	Name       string `json:"name"`
+	Alias      string `json:"alias,omitempty"`
+	Version    string `json:"version"`
+	Repository string `json:"repository,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+}
+
+type chartMappingOptions struct {
+	OutputPath  string
+	HistoryRef  string
+	Dependency  string
+	HelmIndexes []string
+	ChartDirs   []string
+	CacheDir    string
+	Changelog   string
+	Since       string
+	Until       string
+}
+
+func newChartMappingCmd() *cobra.Command {
+	opts := chartMappingOptions{HistoryRef: "HEAD", Dependency: collectorDependencyName}
+
+	cmd := &cobra.Command{
+		Use:   "chart-mapping <Chart.yaml>",
+		Short: "Generate chart to collector version mapping for one chart",
+		Long: `Generate the chart version mapping for one chart from its git history.
+
+Every mapping lists the versions of all chart dependencies. The tracked
+dependency (opentelemetry-collector by default) is also reported as
+collector_chart_version; snapshots without it are skipped.
+
+With --helm-index or --chart-dir, the appVersion of each dependency is
+resolved from a local Helm repository index.yaml or from a directory of
+packaged charts (<name>-<version>.tgz). Dependencies that cannot be resolved
+are listed without an app_version.
+
+Each mapping lists the newest dependencies of the chart version and records
+the commit that introduced the chart version with them, its commit timestamp
+and the release date of that version in the chart changelog (the nearest
+CHANGELOG.md unless --changelog is set). --since and --until keep the
+mappings introduced in that window; they accept a date (2006-01-02) or an
+RFC 3339 timestamp and both ends are inclusive.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runChartMapping(args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().StringVar(&opts.HistoryRef, "ref", "HEAD", "Git ref whose history will be scanned")
+	cmd.Flags().StringSliceVar(&opts.HelmIndexes, "helm-index", nil, "Helm repository index.yaml used to resolve dependency appVersions (repeatable)")
+	cmd.Flags().StringSliceVar(&opts.ChartDirs, "chart-dir", nil, "Directory of packaged charts used to resolve dependency appVersions (repeatable)")
+	cmd.Flags().StringVar(&opts.Changelog, "changelog", "", "CHANGELOG.md providing release dates (defaults to discovery from the chart)")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Only include chart versions introduced at or after this date")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only include chart versions introduced at or before this date")
+	cmd.Flags().StringVar(&opts.CacheDir, "cache-dir", "", "Directory caching parsed Chart.yaml blobs between runs (disabled when empty)")
+	addDependencyFlag(cmd, &opts.Dependency)
+
+	return cmd
+}
+
+func runChartMapping(chartFile string, opts chartMappingOptions) error {
+	repoRoot, err := gitRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	return runChartMappingWithRepoRoot(repoRoot, chartFile, opts)
+}
+
+func runChartMappingWithRepoRoot(repoRoot, chartFile string, opts chartMappingOptions) error {
+	chartFile, err := repoRelativeChartFile(repoRoot, chartFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(repoRoot, chartFile)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("ERROR: chart file not found: %s", chartFile)
+		}
+		return fmt.Errorf("ERROR: failed to access chart file %s: %w", chartFile, err)
+	}
+
+	result, err := buildChartMapping(repoRoot, chartFile, opts)
+	if err != nil {
+		return err
+	}
+
+	output, err := marshalChartMappingJSON(result)
+	if err != nil {
+		return err
+	}
+
+	if opts.OutputPath == "" || opts.OutputPath == "-" {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("ERROR: failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(opts.OutputPath, output, 0o644); err != nil {
+		return fmt.Errorf("ERROR: failed to write %s: %w", opts.OutputPath, err)
+	}
+
+	fmt.Printf("Wrote %s with %d mappings\n", opts.OutputPath, len(result.Mappings))
+
+	return nil
+}
+
+func buildChartMapping(repoRoot, chartFile string, opts chartMappingOptions) (chartMappingResult, error) {
+	dependencyName := opts.Dependency
+	if dependencyName == "" {
+		dependencyName = collectorDependencyName
+	}
+
+	since, err := parseMappingTime(opts.Since, false)
+	if err != nil {
+		return chartMappingResult{}, err
+	}
+	until, err := parseMappingTime(opts.Until, true)
+	if err != nil {
+		return chartMappingResult{}, err
+	}
+
+	resolver, err := newAppVersionResolver(opts.HelmIndexes, opts.ChartDirs)
+	if err != nil {
+		return chartMappingResult{}, err
+	}
+
+	releaseDates, err := loadReleaseDates(repoRoot, chartFile, opts.Changelog)
+	if err != nil {
+		return chartMappingResult{}, err
+	}
+
+	snapshots, err := readChartHistory(repoRoot, opts.HistoryRef, chartFile, opts.CacheDir)
+	if err != nil {
+		return chartMappingResult{}, err
+	}
+
+	mappings := []chartMappingEntry{}
+	introduced := []time.Time{}
+	positions := map[string]int{}
+	dependencies := map[string][]helmlog.ChartDependency{}
+	settled := map[string]bool{}
+	for _, snapshot := range snapshots {
+		chart := snapshot.Chart
+		if chart.Version == "" {
+			continue
+		}
+
+		// History is newest first and a mapping lists the newest
+		// dependencies of its version, so older snapshots move the commit
+		// back for as long as they have the same dependencies.
+		if i, ok := positions[chart.Version]; ok {
+			if settled[chart.Version] || !slices.Equal(chart.Dependencies, dependencies[chart.Version]) {
+				settled[chart.Version] = true
+				continue
+			}
+			mappings[i].Commit = snapshot.Commit
+			mappings[i].CommitTimestamp = snapshot.Time.Format(time.RFC3339)
+			introduced[i] = snapshot.Time
+			continue
+		}
+
+		tracked, ok := chart.Dependency(dependencyName)
+		if !ok || tracked.Version == "" {
+			continue
+		}
+		positions[chart.Version] = len(mappings)
+		dependencies[chart.Version] = chart.Dependencies
+
+		entry := chartMappingEntry{
+			ChartVersion:          chart.Version,
+			CollectorChartVersion: tracked.Version,
+			ReleaseDate:           releaseDates[trimVersionPrefix(chart.Version)],
+			Commit:                snapshot.Commit,
+			CommitTimestamp:       snapshot.Time.Format(time.RFC3339),
+		}
+		for _, dependency := range chart.Dependencies {
+			appVersion, err := resolver.resolve(dependency)
+			if err != nil {
+				return chartMappingResult{}, err
+			}
+
+			entry.Dependencies = append(entry.Dependencies, chartMappingDependency{
+				Name:       dependency.Name,
+				Alias:      dependency.Alias,
+				Version:    dependency.Version,
+				Repository: dependency.Repository,
+				AppVersion: appVersion,
+			})
+			if dependency == tracked {
+				entry.CollectorAppVersion = appVersion
+			}
+		}
+
+		mappings = append(mappings, entry)
+		introduced = append(introduced, snapshot.Time)
+	}
+
+	result := chartMappingResult{Mappings: make([]chartMappingEntry, 0, len(mappings))}
+	for i, entry := range mappings {
+		entry.CollectorChanged = i == len(mappings)-1 || entry.CollectorChartVersion != mappings[i+1].CollectorChartVersion
+
+		if !since.IsZero() && introduced[i].Before(since) {
+			continue
+		}
+		if !until.IsZero() && !introduced[i].Before(until) {
+			continue
+		}
+
+		result.Mappings = append(result.Mappings, entry)
+	}
+
+	return result, nil
+}
+
+// parseMappingTime parses a --since or --until value. A bare date covers the
+// whole day, so for the upper bound it resolves to the start of the next day,
+// which callers treat as exclusive.
+func parseMappingTime(value string, upper bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if upper {
+			return date.AddDate(0, 0, 1), nil
+		}
+		return date, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ERROR: invalid time %q: use a date such as 2006-01-02 or an RFC 3339 timestamp", value)
+	}
+	if upper {
+		return timestamp.Add(time.Second), nil
+	}
+
+	return timestamp, nil
+}
+
+// loadReleaseDates maps the releases of the chart changelog to their dates,
+// keyed by version without the "v" prefix. Without an explicit changelog a
+// chart that has none gets no release dates; validation problems are left to
+// helmlog validate.
+func loadReleaseDates(repoRoot, chartFile, changelogPath string) (map[string]string, error) {
+	if changelogPath == "" {
+		found, err := findChangelogFile(filepath.Join(repoRoot, filepath.Dir(chartFile)))
+		if err != nil {
+			return map[string]string{}, nil
+		}
+		changelogPath = found
+	}
+
+	result, _, err := parseFileDiagnostics(changelogPath, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	dates := map[string]string{}
+	for _, release := range result.Log.Releases {
+		if _, ok := dates[trimVersionPrefix(release.Version)]; !ok {
+			dates[trimVersionPrefix(release.Version)] = release.Date
+		}
+	}
+
+	return dates, nil
+}
+
+// extractVersionsFromChart returns the chart version and the version of the
+// dependency installed as dependencyName, which is empty when the chart does
+// not have it.
+func extractVersionsFromChart(chartContent, dependencyName string) (string, string, error) {
+	chart, err := helmlog.ParseChart([]byte(chartContent))
+	if err != nil {
+		return "", "", err
+	}
+
+	dependency, _ := chart.Dependency(dependencyName)
+	return chart.Version, dependency.Version, nil
+}
+
+func marshalChartMappingJSON(mapping chartMappingResult) ([]byte, error) {
+	output, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to encode json: %w", err)
+	}
+
+	output = append(output, '\n')
+	return output, nil
+}
+
+// appVersionResolver looks up the appVersion of a dependency chart version in
+// local Helm repository indexes first and packaged charts second. Results are
+// cached because most chart versions share their dependency versions.
+type appVersionResolver struct {
+	indexes   []helmlog.Index
+	chartDirs []string
+	cache     map[string]string
+}
+
+func newAppVersionResolver(indexPaths, chartDirs []string) (*appVersionResolver, error) {
+	resolver := &appVersionResolver{chartDirs: chartDirs, cache: map[string]string{}}
+	for _, path := range indexPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+		}
+
+		index, err := helmlog.ParseIndex(content)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: %s: %w", path, err)
+		}
+		resolver.indexes = append(resolver.indexes, index)
+	}
+
+	return resolver, nil
+}
+
+// resolve returns the appVersion of dependency, or "" when no index or
+// packaged chart knows it.
+func (r *appVersionResolver) resolve(dependency helmlog.ChartDependency) (string, error) {
+	if len(r.indexes) == 0 && len(r.chartDirs) == 0 {
+		return "", nil
+	}
+
+	key := dependency.Name + "@" + dependency.Version
+	if appVersion, ok := r.cache[key]; ok {
+		return appVersion, nil
+	}
+
+	appVersion, err := r.lookup(dependency.Name, dependency.Version)
+	if err != nil {
+		return "", err
+	}
+	r.cache[key] = appVersion
+
+	return appVersion, nil
+}
+
+func (r *appVersionResolver) lookup(name, version string) (string, error) {
+	for _, index := range r.indexes {
+		if entry, ok := index.Lookup(name, version); ok && entry.AppVersion != "" {
+			return entry.AppVersion, nil
+		}
+	}
+
+	for _, dir := range r.chartDirs {
+		for _, candidate := range []string{version, trimVersionPrefix(version)} {
+			path := filepath.Join(dir, name+"-"+candidate+".tgz")
+			file, err := os.Open(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("ERROR: failed to open %s: %w", path, err)
+			}
+
+			chart, err := helmlog.ReadChartArchive(file)
+			file.Close()
+			if err != nil {
+				return "", fmt.Errorf("ERROR: %s: %w", path, err)
+			}
+			if chart.AppVersion != "" {
+				return chart.AppVersion, nil
+			}
+		}
+	}
+
+	return "", nil
+}
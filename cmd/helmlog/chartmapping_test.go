@@ -0,0 +1,288 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildChartMappingResolvesAppVersions(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-integration", "k8s-helm", "Chart.yaml"))
+
+	chart := `apiVersion: v2
+name: otel-integration
+version: 0.0.3
+dependencies:
+  - name: opentelemetry-collector
+    alias: opentelemetry-agent
+    version: "0.102.0"
+    repository: https://cgx.jfrog.io/artifactory/coralogix-charts-virtual
+  - name: coralogix-ebpf-profiler
+    version: "0.0.16"
+  - name: coralogix-operator
+    version: "2.1.0"
+`
+	writeTestFile(t, filepath.Join(repoRoot, "otel-integration", "k8s-helm", "Chart.yaml"), chart)
+	runTestGit(t, repoRoot, "commit", "-am", "main add dependencies")
+
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "index.yaml")
+	writeTestFile(t, indexPath, `apiVersion: v1
+entries:
+  opentelemetry-collector:
+    - name: opentelemetry-collector
+      version: 0.102.0
+      appVersion: 0.128.0
+    - name: opentelemetry-collector
+      version: 0.101.0
+      appVersion: 0.127.0
+`)
+	chartDir := filepath.Join(dir, "charts")
+	writeTestChartArchive(t, filepath.Join(chartDir, "coralogix-ebpf-profiler-0.0.16.tgz"), "coralogix-ebpf-profiler", "0.0.16", "1.4.0")
+
+	result, err := buildChartMapping(repoRoot, filepath.Join("otel-integration", "k8s-helm", "Chart.yaml"), chartMappingOptions{
+		HistoryRef:  "HEAD",
+		Dependency:  "opentelemetry-agent",
+		HelmIndexes: []string{indexPath},
+		ChartDirs:   []string{chartDir},
+	})
+	if err != nil {
+		t.Fatalf("buildChartMapping() error = %v", err)
+	}
+
+	want := []chartMappingEntry{{
+		ChartVersion:          "0.0.3",
+		CollectorChartVersion: "0.102.0",
+		CollectorAppVersion:   "0.128.0",
+		CollectorChanged:      true,
+		Dependencies: []chartMappingDependency{
+			{Name: "opentelemetry-collector", Alias: "opentelemetry-agent", Version: "0.102.0", Repository: "https://cgx.jfrog.io/artifactory/coralogix-charts-virtual", AppVersion: "0.128.0"},
+			{Name: "coralogix-ebpf-profiler", Version: "0.0.16", AppVersion: "1.4.0"},
+			{Name: "coralogix-operator", Version: "2.1.0"},
+		},
+	}}
+	if !reflect.DeepEqual(withoutCommits(result.Mappings), want) {
+		t.Fatalf("mappings = %#v, want %#v", withoutCommits(result.Mappings), want)
+	}
+}
+
+func TestBuildChartMappingRejectsInvalidHelmIndex(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
+
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	writeTestFile(t, indexPath, "entries: [\n")
+
+	_, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), chartMappingOptions{HelmIndexes: []string{indexPath}})
+	if err == nil {
+		t.Fatalf("buildChartMapping() error = nil, want invalid index error")
+	}
+}
+
+func TestBuildChartMappingRecordsCommitsAndReleaseDates(t *testing.T) {
+	chartPath := filepath.Join("otel-linux-standalone", "Chart.yaml")
+	repoRoot := setupChartMappingTestRepo(t, chartPath)
+
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-02T10:00:00Z")
+	writeTestChart(t, repoRoot, "0.0.3", "0.101.0")
+	runTestGit(t, repoRoot, "commit", "-am", "main release 0.0.3")
+
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-05T10:00:00Z")
+	writeTestFile(t, filepath.Join(repoRoot, chartPath), `apiVersion: v2
+name: linux-standalone
+version: 0.0.3
+dependencies:
+  - name: opentelemetry-collector
+    version: "0.101.0"
+  - name: coralogix-operator
+    version: "2.1.0"
+`)
+	runTestGit(t, repoRoot, "commit", "-am", "main add operator in 0.0.3")
+	introduced := strings.TrimSpace(runTestGit(t, repoRoot, "rev-parse", "HEAD"))
+
+	writeTestFile(t, filepath.Join(repoRoot, "otel-linux-standalone", "CHANGELOG.md"), `## OpenTelemetry Linux Standalone
+
+### v0.0.3 / 2030-01-06
+- [Feat] Bump collector
+
+### v0.0.2 / 2026-01-01
+- [Fix] Something
+`)
+
+	result, err := buildChartMapping(repoRoot, chartPath, chartMappingOptions{HistoryRef: "HEAD"})
+	if err != nil {
+		t.Fatalf("buildChartMapping() error = %v", err)
+	}
+	if len(result.Mappings) != 3 {
+		t.Fatalf("len(Mappings) = %d, want 3", len(result.Mappings))
+	}
+
+	newest := result.Mappings[0]
+	if newest.Commit != introduced || newest.CommitTimestamp != "2030-01-05T10:00:00Z" {
+		t.Fatalf("newest commit = %s at %s, want %s at 2030-01-05T10:00:00Z", newest.Commit, newest.CommitTimestamp, introduced)
+	}
+	if newest.CollectorChanged || len(newest.Dependencies) != 2 {
+		t.Fatalf("newest = %#v, want the latest snapshot with an unchanged collector", newest)
+	}
+	if !result.Mappings[1].CollectorChanged || !result.Mappings[2].CollectorChanged {
+		t.Fatalf("mappings = %#v, want older collector changes", result.Mappings)
+	}
+	if newest.ReleaseDate != "2030-01-06" {
+		t.Fatalf("ReleaseDate = %q, want %q", newest.ReleaseDate, "2030-01-06")
+	}
+	if got := result.Mappings[1].ReleaseDate; got != "2026-01-01" {
+		t.Fatalf("ReleaseDate = %q, want %q", got, "2026-01-01")
+	}
+	if got := result.Mappings[2].ReleaseDate; got != "" {
+		t.Fatalf("ReleaseDate = %q, want empty for a release missing from the changelog", got)
+	}
+
+	for _, tc := range []struct {
+		since, until string
+		want         int
+	}{
+		{since: "2030-01-05", until: "2030-01-05", want: 1},
+		{since: "2030-01-05T10:00:00Z", want: 1},
+		{since: "2030-01-05T10:00:01Z", want: 0},
+		{until: "2030-01-05T09:59:59Z", want: 2},
+	} {
+		result, err := buildChartMapping(repoRoot, chartPath, chartMappingOptions{HistoryRef: "HEAD", Since: tc.since, Until: tc.until})
+		if err != nil {
+			t.Fatalf("buildChartMapping(since=%q, until=%q) error = %v", tc.since, tc.until, err)
+		}
+		if len(result.Mappings) != tc.want {
+			t.Fatalf("buildChartMapping(since=%q, until=%q) = %d mappings, want %d", tc.since, tc.until, len(result.Mappings), tc.want)
+		}
+	}
+
+	// Filtering must not change what a mapping is compared with.
+	result, err = buildChartMapping(repoRoot, chartPath, chartMappingOptions{HistoryRef: "HEAD", Since: "2030-01-01"})
+	if err != nil {
+		t.Fatalf("buildChartMapping() error = %v", err)
+	}
+	if len(result.Mappings) != 1 || result.Mappings[0].CollectorChanged {
+		t.Fatalf("mappings = %#v, want 0.0.3 with an unchanged collector", result.Mappings)
+	}
+
+	if _, err := buildChartMapping(repoRoot, chartPath, chartMappingOptions{Since: "last week"}); err == nil {
+		t.Fatalf("buildChartMapping() error = nil for invalid --since")
+	}
+}
+
+func TestBuildChartMappingDependencyChangeWithoutVersionBump(t *testing.T) {
+	chartPath := filepath.Join("otel-linux-standalone", "Chart.yaml")
+	repoRoot := setupChartMappingTestRepo(t, chartPath)
+
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-02T10:00:00Z")
+	writeTestChart(t, repoRoot, "0.0.3", "0.102.0")
+	runTestGit(t, repoRoot, "commit", "-am", "main release 0.0.3")
+
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-05T10:00:00Z")
+	writeTestChart(t, repoRoot, "0.0.3", "0.103.0")
+	runTestGit(t, repoRoot, "commit", "-am", "main bump collector in 0.0.3")
+	bumped := strings.TrimSpace(runTestGit(t, repoRoot, "rev-parse", "HEAD"))
+
+	// A later change that keeps the dependencies does not move the commit
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-07T10:00:00Z")
+	writeTestFile(t, filepath.Join(repoRoot, chartPath), `apiVersion: v2
+name: linux-standalone
+description: Linux standalone collector
+version: 0.0.3
+dependencies:
+  - name: opentelemetry-collector
+    version: "0.103.0"
+`)
+	runTestGit(t, repoRoot, "commit", "-am", "main describe 0.0.3")
+
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	writeTestFile(t, indexPath, `apiVersion: v1
+entries:
+  opentelemetry-collector:
+    - name: opentelemetry-collector
+      version: 0.103.0
+      appVersion: 0.129.0
+    - name: opentelemetry-collector
+      version: 0.102.0
+      appVersion: 0.128.0
+`)
+
+	result, err := buildChartMapping(repoRoot, chartPath, chartMappingOptions{HistoryRef: "HEAD", HelmIndexes: []string{indexPath}})
+	if err != nil {
+		t.Fatalf("buildChartMapping() error = %v", err)
+	}
+	if len(result.Mappings) != 3 {
+		t.Fatalf("len(Mappings) = %d, want 3", len(result.Mappings))
+	}
+
+	newest := result.Mappings[0]
+	if newest.Commit != bumped || newest.CommitTimestamp != "2030-01-05T10:00:00Z" {
+		t.Fatalf("newest commit = %s at %s, want %s at 2030-01-05T10:00:00Z", newest.Commit, newest.CommitTimestamp, bumped)
+	}
+	want := collectorMapping("0.0.3", "0.103.0")
+	want.CollectorAppVersion = "0.129.0"
+	want.Dependencies[0].AppVersion = "0.129.0"
+	if got := withoutCommits(result.Mappings[:1])[0]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("newest = %#v, want %#v", got, want)
+	}
+}
+
+func collectorMapping(chartVersion, collectorVersion string) chartMappingEntry {
+	return chartMappingEntry{
+		ChartVersion:          chartVersion,
+		CollectorChartVersion: collectorVersion,
+		CollectorChanged:      true,
+		Dependencies: []chartMappingDependency{
+			{Name: collectorDependencyName, Version: collectorVersion},
+		},
+	}
+}
+
+// withoutCommits clears the commit hashes and timestamps, which differ on
+// every run of the test repositories.
+func withoutCommits(mappings []chartMappingEntry) []chartMappingEntry {
+	result := make([]chartMappingEntry, 0, len(mappings))
+	for _, mapping := range mappings {
+		mapping.Commit = ""
+		mapping.CommitTimestamp = ""
+		result = append(result, mapping)
+	}
+
+	return result
+}
+
+func writeTestChartArchive(t *testing.T, path, name, version, appVersion string) {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(gz)
+	for _, file := range []struct{ name, content string }{
+		{name + "/charts/common/Chart.yaml", "apiVersion: v2\nname: common\nversion: 1.0.0\nappVersion: 9.9.9\n"},
+		{name + "/templates/deployment.yaml", "kind: Deployment\n"},
+		{name + "/Chart.yaml", "apiVersion: v2\nname: " + name + "\nversion: " + version + "\nappVersion: " + appVersion + "\n"},
+	} {
+		if err := archive.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("WriteHeader() error = %v", err)
+		}
+		if _, err := archive.Write([]byte(file.content)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("tar Close() error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close() error = %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("os.MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, buffer.Bytes(), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+const collectorDependencyName = "opentelemetry-collector"
+
+type checkReleaseOptions struct {
+	parseOptions
+	ChartPath     string
+	ChangelogPath string
+	BaseRef       string
+	Dependency    string
+}
+
+func newCheckReleaseCmd() *cobra.Command {
+	opts := checkReleaseOptions{Dependency: collectorDependencyName}
+
+	cmd := &cobra.Command{
+		Use:   "check-release <chart-dir>",
+		Short: "Check that Chart.yaml and the changelog describe the same release",
+		Long: `Check that the chart version in Chart.yaml matches the newest changelog
+release, and that the tracked dependency version (opentelemetry-collector by
+default) has a "Bump chart dependency to <chart> X" entry.
+
+Chart.yaml is looked up in <chart-dir> and <chart-dir>/k8s-helm; the changelog
+is the nearest CHANGELOG.md at or above the chart. With --base-ref, the
+dependency entry is only required when the version differs from the one at
+that ref, and it must be part of the newest release.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runCheckRelease(args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ChartPath, "chart", "", "Chart.yaml path (defaults to discovery from <chart-dir>)")
+	cmd.Flags().StringVar(&opts.ChangelogPath, "changelog", "", "CHANGELOG.md path (defaults to discovery from <chart-dir>)")
+	cmd.Flags().StringVar(&opts.BaseRef, "base-ref", "", "Git ref to compare the dependency version against, such as origin/master")
+	addDependencyFlag(cmd, &opts.Dependency)
+	addParseFlags(cmd, &opts.parseOptions)
+
+	return cmd
+}
+
+func runCheckRelease(chartDir string, opts checkReleaseOptions) error {
+	chartPath := opts.ChartPath
+	if chartPath == "" {
+		found, err := findChartFile(chartDir)
+		if err != nil {
+			return err
+		}
+		chartPath = found
+	}
+
+	changelogPath := opts.ChangelogPath
+	if changelogPath == "" {
+		found, err := findChangelogFile(filepath.Dir(chartPath))
+		if err != nil {
+			return err
+		}
+		changelogPath = found
+	}
+
+	chartContent, err := os.ReadFile(chartPath)
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to read %s: %w", chartPath, err)
+	}
+
+	chart, err := helmlog.ParseChart(chartContent)
+	if err != nil {
+		return fmt.Errorf("ERROR: %s: %w", chartPath, err)
+	}
+	if chart.Version == "" {
+		return fmt.Errorf("ERROR: %s has no chart version", chartPath)
+	}
+
+	if opts.Dependency == "" {
+		opts.Dependency = collectorDependencyName
+	}
+	dependency, hasDependency := chart.Dependency(opts.Dependency)
+
+	result, err := parseFile(changelogPath, opts.parseOptions)
+	if err != nil {
+		return err
+	}
+	if len(result.Log.Releases) == 0 {
+		return fmt.Errorf("ERROR: %s has no releases to compare with %s version %s", changelogPath, chartPath, chart.Version)
+	}
+	helmlog.Sort(&result.Log)
+	newest := result.Log.Releases[0]
+
+	problems := []string{}
+	if trimVersionPrefix(newest.Version) != trimVersionPrefix(chart.Version) {
+		problems = append(problems, fmt.Sprintf("ERROR: %s version %s does not match the newest release %s in %s", chartPath, chart.Version, newest.Version, changelogPath))
+	}
+
+	if hasDependency {
+		required, err := dependencyBumpRequired(chartPath, dependency, opts.Dependency, opts.BaseRef)
+		if err != nil {
+			return err
+		}
+
+		if required {
+			content, err := os.ReadFile(changelogPath)
+			if err != nil {
+				return fmt.Errorf("ERROR: failed to read %s: %w", changelogPath, err)
+			}
+
+			// With a base ref the entry must be part of the newest release,
+			// picked by the same date and version order as above.
+			release := ""
+			if opts.BaseRef != "" {
+				release = newest.Version
+			}
+			if !hasDependencyBumpEntry(string(content), opts.dialectName(), dependency, release) {
+				scope := changelogPath
+				if opts.BaseRef != "" {
+					scope = fmt.Sprintf("release %s of %s", newest.Version, changelogPath)
+				}
+				problems = append(problems, fmt.Sprintf("ERROR: %s dependency is at %s but %s has no %q entry", opts.Dependency, dependency.Version, scope, dependencyBumpPhrase(dependency)))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	fmt.Printf("OK: %s version %s matches %s\n", chartPath, chart.Version, changelogPath)
+	if hasDependency {
+		fmt.Printf("%s dependency: %s\n", opts.Dependency, dependency.Version)
+	}
+
+	return nil
+}
+
+// findChartFile looks for Chart.yaml in dir and in dir/k8s-helm, where the
+// integration charts keep it next to their templates.
+func findChartFile(dir string) (string, error) {
+	for _, candidate := range []string{filepath.Join(dir, "Chart.yaml"), filepath.Join(dir, "k8s-helm", "Chart.yaml")} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("ERROR: no Chart.yaml found in %s", dir)
+}
+
+// findChangelogFile returns the nearest CHANGELOG.md in dir or one of its
+// parents, stopping at the repository root.
+func findChangelogFile(dir string) (string, error) {
+	for current := dir; ; current = filepath.Join(current, "..") {
+		candidate := filepath.Join(current, changelogFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+
+		absCurrent, err := filepath.Abs(current)
+		if err != nil {
+			return "", fmt.Errorf("ERROR: failed to resolve %s: %w", current, err)
+		}
+		if _, err := os.Stat(filepath.Join(absCurrent, ".git")); err == nil || filepath.Dir(absCurrent) == absCurrent {
+			break
+		}
+	}
+
+	return "", fmt.Errorf("ERROR: no CHANGELOG.md found for %s", dir)
+}
+
+// dependencyBumpRequired reports whether the dependency version needs a bump
+// entry. Without a base ref every version does; with one only a version that
+// differs from the chart at that ref.
+func dependencyBumpRequired(chartPath string, dependency helmlog.ChartDependency, dependencyName, baseRef string) (bool, error) {
+	if baseRef == "" {
+		return true, nil
+	}
+
+	repoRoot, err := gitRepoRoot()
+	if err != nil {
+		return false, err
+	}
+
+	absChart, err := filepath.Abs(chartPath)
+	if err != nil {
+		return false, fmt.Errorf("ERROR: failed to resolve %s: %w", chartPath, err)
+	}
+
+	chartFile, err := repoRelativeChartFile(repoRoot, absChart)
+	if err != nil {
+		return false, err
+	}
+
+	baseContent, err := runGitCommand(repoRoot, "show", fmt.Sprintf("%s:%s", baseRef, filepath.ToSlash(chartFile)))
+	if err != nil {
+		// A chart that does not exist at the base ref is new, so its
+		// dependency counts as bumped.
+		if isMissingAtRef(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("ERROR: failed to read %s at %s: %w", chartFile, baseRef, err)
+	}
+
+	_, baseVersion, err := extractVersionsFromChart(baseContent, dependencyName)
+	if err != nil {
+		return true, nil
+	}
+
+	return baseVersion != dependency.Version, nil
+}
+
+// isMissingAtRef reports whether git show failed because the path does not
+// exist at the ref, as opposed to an unknown ref or a broken repository.
+func isMissingAtRef(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "does not exist in") || strings.Contains(message, "exists on disk, but not in")
+}
+
+func dependencyBumpPhrase(dependency helmlog.ChartDependency) string {
+	return "Bump chart dependency to " + dependency.Name + " " + dependency.Version
+}
+
+// hasDependencyBumpEntry looks for a bump entry naming version. The entries
+// are dropped from the parsed changelog, so the raw text is searched. With a
+// release version, only the lines of that release count.
+func hasDependencyBumpEntry(content, dialectName string, dependency helmlog.ChartDependency, release string) bool {
+	lines := strings.Split(content, "\n")
+	dialect, err := resolveDialect(dialectName, lines)
+	if err != nil {
+		return false
+	}
+
+	pattern := regexp.MustCompile(`Bump chart dependency to ` + regexp.QuoteMeta(dependency.Name) + ` v?(` + helmlog.SemverExpr + `)`)
+
+	inRelease := release == ""
+	for _, line := range lines {
+		if header := dialect.ReleaseHeader.FindStringSubmatch(strings.TrimSpace(line)); header != nil {
+			inRelease = release == "" || trimVersionPrefix(header[1]) == trimVersionPrefix(release)
+			continue
+		}
+		if !inRelease {
+			continue
+		}
+
+		for _, matches := range pattern.FindAllStringSubmatch(line, -1) {
+			if trimVersionPrefix(matches[1]) == trimVersionPrefix(dependency.Version) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func trimVersionPrefix(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
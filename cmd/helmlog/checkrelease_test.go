@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+const checkReleaseTestChangelog = `# Changelog
+
+### v0.0.2 / 2026-01-02
+- [Chore] Bump chart dependency to opentelemetry-collector 0.101.0
+
+### v0.0.1 / 2026-01-01
+- [Chore] Bump chart dependency to opentelemetry-collector 0.100.0
+- [Feat] Initial release
+`
+
+func TestRunCheckReleaseMatchingChart(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-integration", "k8s-helm", "Chart.yaml"))
+	t.Chdir(repoRoot)
+	writeTestFile(t, filepath.Join("otel-integration", "CHANGELOG.md"), checkReleaseTestChangelog)
+
+	if err := runCheckRelease("otel-integration", checkReleaseOptions{}); err != nil {
+		t.Fatalf("runCheckRelease() error = %v", err)
+	}
+	if err := runCheckRelease("otel-integration", checkReleaseOptions{BaseRef: "HEAD~1"}); err != nil {
+		t.Fatalf("runCheckRelease(--base-ref) error = %v", err)
+	}
+}
+
+func TestRunCheckReleaseReportsVersionMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeTestChartAt(t, dir, "Chart.yaml", "0.0.3", "0.101.0")
+	writeTestFile(t, filepath.Join(dir, "CHANGELOG.md"), checkReleaseTestChangelog)
+
+	err := runCheckRelease(dir, checkReleaseOptions{})
+	if err == nil || !strings.Contains(err.Error(), "version 0.0.3 does not match the newest release v0.0.2") {
+		t.Fatalf("runCheckRelease() error = %v, want version mismatch", err)
+	}
+}
+
+func TestRunCheckReleaseReportsChangelogWithoutReleases(t *testing.T) {
+	dir := t.TempDir()
+	writeTestChartAt(t, dir, "Chart.yaml", "0.0.1", "0.101.0")
+	writeTestFile(t, filepath.Join(dir, "CHANGELOG.md"), "# Changelog\n")
+
+	opts := checkReleaseOptions{}
+	opts.DisabledRules = []string{helmlog.RuleNoReleases}
+	err := runCheckRelease(dir, opts)
+	if err == nil || !strings.Contains(err.Error(), "has no releases to compare with") {
+		t.Fatalf("runCheckRelease() error = %v, want no releases", err)
+	}
+}
+
+func TestRunCheckReleaseRequiresDependencyBumpEntry(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
+	t.Chdir(repoRoot)
+
+	changelog := strings.Replace(checkReleaseTestChangelog, "- [Chore] Bump chart dependency to opentelemetry-collector 0.101.0", "- [Fix] Something else", 1)
+	writeTestFile(t, filepath.Join("otel-linux-standalone", "CHANGELOG.md"), changelog)
+
+	err := runCheckRelease("otel-linux-standalone", checkReleaseOptions{})
+	if err == nil || !strings.Contains(err.Error(), `no "Bump chart dependency to opentelemetry-collector 0.101.0" entry`) {
+		t.Fatalf("runCheckRelease() error = %v, want missing bump entry", err)
+	}
+
+	// The dependency did not change since HEAD, so no entry is needed.
+	if err := runCheckRelease("otel-linux-standalone", checkReleaseOptions{BaseRef: "HEAD"}); err != nil {
+		t.Fatalf("runCheckRelease(--base-ref HEAD) error = %v", err)
+	}
+
+	// An entry in an older release does not cover a bump since the base ref.
+	changelog = strings.Replace(checkReleaseTestChangelog, "0.100.0", "0.101.0", 1)
+	changelog = strings.Replace(changelog, "- [Chore] Bump chart dependency to opentelemetry-collector 0.101.0\n\n", "- [Fix] Something else\n\n", 1)
+	writeTestFile(t, filepath.Join("otel-linux-standalone", "CHANGELOG.md"), changelog)
+	err = runCheckRelease("otel-linux-standalone", checkReleaseOptions{BaseRef: "HEAD~1"})
+	if err == nil || !strings.Contains(err.Error(), "release v0.0.2 of") {
+		t.Fatalf("runCheckRelease(--base-ref HEAD~1) error = %v, want missing bump entry in newest release", err)
+	}
+}
+
+func TestRunCheckReleaseUsesNewestReleaseForBumpEntry(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
+	t.Chdir(repoRoot)
+
+	// v0.0.2 is listed below v0.0.1, so the entry of the first block in the
+	// file is not part of the newest release.
+	changelog := `# Changelog
+
+### v0.0.1 / 2026-01-01
+- [Chore] Bump chart dependency to opentelemetry-collector 0.101.0
+
+### v0.0.2 / 2026-01-02
+- [Fix] Something else
+`
+	writeTestFile(t, filepath.Join("otel-linux-standalone", "CHANGELOG.md"), changelog)
+
+	opts := checkReleaseOptions{BaseRef: "HEAD~1"}
+	opts.DisabledRules = []string{helmlog.RuleReleaseOrder, helmlog.RuleReleaseDateOrder}
+	err := runCheckRelease("otel-linux-standalone", opts)
+	if err == nil || !strings.Contains(err.Error(), "release v0.0.2 of") {
+		t.Fatalf("runCheckRelease(--base-ref HEAD~1) error = %v, want missing bump entry in v0.0.2", err)
+	}
+}
+
+func TestRunCheckReleaseReportsGitErrors(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
+	t.Chdir(repoRoot)
+	writeTestFile(t, filepath.Join("otel-linux-standalone", "CHANGELOG.md"), checkReleaseTestChangelog)
+
+	err := runCheckRelease("otel-linux-standalone", checkReleaseOptions{BaseRef: "no-such-ref"})
+	if err == nil || !strings.Contains(err.Error(), "failed to read otel-linux-standalone/Chart.yaml at no-such-ref") {
+		t.Fatalf("runCheckRelease(--base-ref no-such-ref) error = %v, want git error", err)
+	}
+
+	// A chart added after the base ref needs the bump entry, which it has.
+	writeTestChartAt(t, repoRoot, filepath.Join("otel-macos-standalone", "Chart.yaml"), "0.0.2", "0.101.0")
+	writeTestFile(t, filepath.Join("otel-macos-standalone", "CHANGELOG.md"), checkReleaseTestChangelog)
+	if err := runCheckRelease("otel-macos-standalone", checkReleaseOptions{BaseRef: "HEAD"}); err != nil {
+		t.Fatalf("runCheckRelease() on a new chart error = %v", err)
+	}
+}
+
+func TestFindChangelogFileWalksUp(t *testing.T) {
+	dir := t.TempDir()
+	chartDir := filepath.Join(dir, "k8s-helm")
+	if err := os.MkdirAll(chartDir, 0o755); err != nil {
+		t.Fatalf("os.MkdirAll() error = %v", err)
+	}
+	writeTestFile(t, filepath.Join(dir, "CHANGELOG.md"), checkReleaseTestChangelog)
+
+	got, err := findChangelogFile(chartDir)
+	if err != nil {
+		t.Fatalf("findChangelogFile() error = %v", err)
+	}
+	if want := filepath.Join(dir, "CHANGELOG.md"); got != want {
+		t.Fatalf("findChangelogFile() = %q, want %q", got, want)
+	}
+}
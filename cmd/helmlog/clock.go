@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// todayCommit makes --today use the committer date of the commit being
+	// validated.
+	todayCommit = "commit"
+	// sourceDateEpochEnv is the reproducible-builds variable holding the
+	// reference time in seconds since the Unix epoch.
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+)
+
+// location returns the --timezone location, time.Local when it is not set.
+func (o parseOptions) location() (*time.Location, error) {
+	if o.Timezone == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: invalid --timezone %q: %w", o.Timezone, err)
+	}
+
+	return location, nil
+}
+
+// referenceTime returns the time release dates are checked against: --today,
+// then SOURCE_DATE_EPOCH, then the clock.
+func (o parseOptions) referenceTime(location *time.Location) (time.Time, error) {
+	switch {
+	case o.Today == todayCommit:
+		return o.commitTime()
+	case o.Today != "":
+		today, err := time.ParseInLocation("2006-01-02", o.Today, location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("ERROR: invalid --today %q (expected YYYY-MM-DD or %q)", o.Today, todayCommit)
+		}
+		return today, nil
+	}
+
+	if epoch, ok := os.LookupEnv(sourceDateEpochEnv); ok {
+		seconds, err := strconv.ParseInt(strings.TrimSpace(epoch), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("ERROR: invalid %s %q: expected seconds since the Unix epoch", sourceDateEpochEnv, epoch)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+
+	return nowFunc(), nil
+}
+
+// commitTime returns the committer date of the revision the changelog was
+// read from, HEAD for the working tree.
+func (o parseOptions) commitTime() (time.Time, error) {
+	revision := o.revision
+	if revision == "" {
+		revision = "HEAD"
+	}
+
+	output, err := runGitCommand("", "log", "-1", "--format=%ct", revision)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ERROR: failed to read the commit date of %s: %w", revision, err)
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ERROR: unexpected commit date %q for %s", strings.TrimSpace(output), revision)
+	}
+
+	return time.Unix(seconds, 0), nil
+}
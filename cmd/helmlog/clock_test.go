@@ -0,0 +1,96 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const clockTestChangelog = `### v0.0.1 / 2026-02-11
+- [Feat] Initial release
+`
+
+func TestParseMarkdownReferenceDate(t *testing.T) {
+	originalNow := nowFunc
+	t.Cleanup(func() {
+		nowFunc = originalNow
+	})
+	// 23:30 UTC on the 10th is already the 11th east of UTC.
+	nowFunc = func() time.Time { return time.Date(2026, 2, 10, 23, 30, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		opts    parseOptions
+		epoch   string
+		wantErr string
+	}{
+		{name: "clock in UTC", opts: parseOptions{Timezone: "UTC"}, wantErr: "release date must not be in the future"},
+		{name: "clock east of UTC", opts: parseOptions{Timezone: "Asia/Tokyo"}},
+		{name: "today flag", opts: parseOptions{Today: "2026-02-11", Timezone: "UTC"}},
+		{name: "today flag before release", opts: parseOptions{Today: "2026-02-10"}, wantErr: "release date must not be in the future"},
+		{name: "source date epoch", opts: parseOptions{Timezone: "UTC"}, epoch: "1770768000"},
+		{name: "today flag wins over epoch", opts: parseOptions{Today: "2026-02-10", Timezone: "UTC"}, epoch: "1770768000", wantErr: "release date must not be in the future"},
+		{name: "invalid today", opts: parseOptions{Today: "tomorrow"}, wantErr: `invalid --today "tomorrow"`},
+		{name: "invalid timezone", opts: parseOptions{Timezone: "Mars/Olympus"}, wantErr: `invalid --timezone "Mars/Olympus"`},
+		{name: "invalid epoch", opts: parseOptions{}, epoch: "yesterday", wantErr: `invalid SOURCE_DATE_EPOCH "yesterday"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.epoch != "" {
+				t.Setenv(sourceDateEpochEnv, tt.epoch)
+			}
+
+			err := parseMarkdownWithOptions(clockTestChangelog, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parse error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownTodayFromCommit(t *testing.T) {
+	repoRoot := t.TempDir()
+	runTestGit(t, repoRoot, "init")
+	writeTestFile(t, filepath.Join(repoRoot, "CHANGELOG.md"), clockTestChangelog)
+	runTestGit(t, repoRoot, "add", "CHANGELOG.md")
+	t.Setenv("GIT_COMMITTER_DATE", "2026-02-10T12:00:00Z")
+	runTestGit(t, repoRoot, "commit", "-m", "Prepare v0.0.1")
+	t.Chdir(repoRoot)
+
+	opts := parseOptions{Today: todayCommit, Timezone: "UTC"}
+	if err := parseMarkdownWithOptions(clockTestChangelog, opts); err == nil || !strings.Contains(err.Error(), "release date must not be in the future") {
+		t.Fatalf("parse error at a commit of the previous day = %v, want future-date error", err)
+	}
+
+	t.Setenv("GIT_COMMITTER_DATE", "2026-02-11T08:00:00Z")
+	runTestGit(t, repoRoot, "commit", "--allow-empty", "-m", "Release v0.0.1")
+	if err := parseMarkdownWithOptions(clockTestChangelog, opts); err != nil {
+		t.Fatalf("parse error at a commit of the release day = %v, want nil", err)
+	}
+
+	// A changelog read from an older revision is checked against that commit.
+	opts.revision = "HEAD~1"
+	if err := parseMarkdownWithOptions(clockTestChangelog, opts); err == nil {
+		t.Fatal("parse error at HEAD~1 = nil, want future-date error")
+	}
+}
+
+func parseMarkdownWithOptions(content string, opts parseOptions) error {
+	_, diagnostics, err := parseMarkdownDiagnostics(strings.NewReader(content), opts)
+	if err != nil {
+		return err
+	}
+	if len(diagnostics) > 0 {
+		return diagnosticError(diagnostics[0])
+	}
+
+	return nil
+}
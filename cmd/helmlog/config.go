@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"gopkg.in/yaml.v3"
+)
+
+const configFileName = ".helmlog.yaml"
+
+// configSettings are the settings of .helmlog.yaml that can be overridden per
+// directory. Nil fields inherit the value from the parent configuration.
+type configSettings struct {
+	helmlog.TaxonomyConfig `yaml:",inline"`
+
+	// Dialect names the changelog dialect used instead of auto-detection.
+	// --dialect wins over it.
+	Dialect string `yaml:"dialect"`
+	// InitialDevelopment lets releases after a 0.0.z version carry breaking
+	// changes without a minor bump. Off by default.
+	InitialDevelopment *bool `yaml:"initial_development"`
+	// Rules turns individual validation rules on or off by rule ID.
+	Rules map[string]bool `yaml:"rules"`
+	// Exceptions lists, per rule ID, releases whose problems are accepted,
+	// such as versions that were published twice.
+	Exceptions map[string][]string `yaml:"exceptions"`
+}
+
+// helmlogConfig is the content of a .helmlog.yaml file.
+type helmlogConfig struct {
+	configSettings `yaml:",inline"`
+
+	Overrides []configOverride `yaml:"overrides"`
+}
+
+// configOverride applies to changelogs below any of Paths, which are
+// relative to the directory holding the configuration file.
+type configOverride struct {
+	configSettings `yaml:",inline"`
+
+	Paths []string `yaml:"paths"`
+}
+
+// changelogConfig is the resolved configuration for one changelog.
+type changelogConfig struct {
+	Taxonomy *helmlog.Taxonomy
+	// Dialect is empty when the dialect is detected from the content.
+	Dialect string
+	// InitialDevelopment exempts releases after a 0.0.z version from the
+	// breaking-change-bump rule.
+	InitialDevelopment bool
+	// Rules holds the rules switched off or on by configuration. Rules not
+	// listed are enabled.
+	Rules      map[string]bool
+	Exceptions map[string][]string
+}
+
+var defaultConfigSettings = configSettings{TaxonomyConfig: helmlog.DefaultTaxonomyConfig()}
+
+var (
+	defaultConfig   = mustBuildConfig(defaultConfigSettings)
+	defaultTaxonomy = defaultConfig.Taxonomy
+)
+
+func mustBuildConfig(settings configSettings) *changelogConfig {
+	config, err := buildConfig(settings)
+	if err != nil {
+		panic(err)
+	}
+
+	return config
+}
+
+func buildConfig(settings configSettings) (*changelogConfig, error) {
+	for rule := range settings.Rules {
+		if err := validateRuleName(rule); err != nil {
+			return nil, err
+		}
+	}
+	for rule := range settings.Exceptions {
+		if err := validateRuleName(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.Dialect != "" {
+		if _, err := helmlog.LookupDialect(settings.Dialect); err != nil {
+			return nil, fmt.Errorf("ERROR: %w", err)
+		}
+	}
+
+	taxonomy, err := helmlog.NewTaxonomy(settings.TaxonomyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %w", err)
+	}
+
+	config := &changelogConfig{Taxonomy: taxonomy, Dialect: settings.Dialect, Rules: settings.Rules, Exceptions: settings.Exceptions}
+	if settings.InitialDevelopment != nil {
+		config.InitialDevelopment = *settings.InitialDevelopment
+	}
+
+	return config, nil
+}
+
+func validateRuleName(rule string) error {
+	if _, ok := helmlog.RuleDescription(rule); !ok {
+		return fmt.Errorf("ERROR: unknown rule %q", rule)
+	}
+
+	return nil
+}
+
+func (c *changelogConfig) ruleEnabled(rule string) bool {
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+func (c *changelogConfig) excepted(d helmlog.Diagnostic) bool {
+	if d.ReleaseVersion == "" {
+		return false
+	}
+
+	for _, version := range c.Exceptions[d.Rule] {
+		if version == d.ReleaseVersion {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o parseOptions) config() *changelogConfig {
+	if o.Config == nil {
+		return defaultConfig
+	}
+
+	return o.Config
+}
+
+func (o parseOptions) tagTaxonomy() *helmlog.Taxonomy {
+	return o.config().Taxonomy
+}
+
+// dialectName returns the --dialect flag, or the configured dialect when the
+// flag is left on auto.
+func (o parseOptions) dialectName() string {
+	name := strings.ToLower(strings.TrimSpace(o.Dialect))
+	if (name == "" || name == helmlog.DialectAuto) && o.config().Dialect != "" {
+		return o.config().Dialect
+	}
+
+	return o.Dialect
+}
+
+// ruleEnabled reports whether a rule is checked. --disable-rule wins over the
+// configuration file.
+func (o parseOptions) ruleEnabled(rule string) bool {
+	for _, disabled := range o.DisabledRules {
+		if disabled == rule {
+			return false
+		}
+	}
+
+	return o.config().ruleEnabled(rule)
+}
+
+// reportable reports whether a diagnostic is kept.
+func (o parseOptions) reportable(d helmlog.Diagnostic) bool {
+	return o.ruleEnabled(d.Rule) && !o.config().excepted(d)
+}
+
+// withConfig loads the configuration for the changelog at path unless one is
+// already set.
+func (o parseOptions) withConfig(path string) (parseOptions, error) {
+	for _, rule := range o.DisabledRules {
+		if err := validateRuleName(rule); err != nil {
+			return o, err
+		}
+	}
+
+	if o.Config != nil {
+		return o, nil
+	}
+
+	config, err := loadConfig(path, o.ConfigPath)
+	if err != nil {
+		return o, err
+	}
+
+	o.Config = config
+	return o, nil
+}
+
+// mergeConfigSettings layers override on top of base. Tag aliases, rule
+// switches and exceptions are added, and an alias moved to another canonical
+// tag leaves its old one; the list settings are replaced when the override
+// sets them.
+func mergeConfigSettings(base, override configSettings) configSettings {
+	merged := configSettings{
+		TaxonomyConfig: helmlog.TaxonomyConfig{
+			Tags:           map[string][]string{},
+			SecondaryTags:  base.SecondaryTags,
+			Severity:       base.Severity,
+			DroppedEntries: base.DroppedEntries,
+		},
+		Dialect:            base.Dialect,
+		InitialDevelopment: base.InitialDevelopment,
+		Rules:              map[string]bool{},
+		Exceptions:         map[string][]string{},
+	}
+	if override.Dialect != "" {
+		merged.Dialect = override.Dialect
+	}
+	if override.InitialDevelopment != nil {
+		merged.InitialDevelopment = override.InitialDevelopment
+	}
+
+	for _, rules := range []map[string]bool{base.Rules, override.Rules} {
+		for rule, enabled := range rules {
+			merged.Rules[rule] = enabled
+		}
+	}
+	for _, exceptions := range []map[string][]string{base.Exceptions, override.Exceptions} {
+		for rule, versions := range exceptions {
+			merged.Exceptions[rule] = append(merged.Exceptions[rule], versions...)
+		}
+	}
+
+	moved := map[string]bool{}
+	for _, aliases := range override.Tags {
+		for _, alias := range aliases {
+			moved[strings.ToLower(alias)] = true
+		}
+	}
+
+	for name, aliases := range base.Tags {
+		kept := make([]string, 0, len(aliases))
+		for _, alias := range aliases {
+			if !moved[strings.ToLower(alias)] {
+				kept = append(kept, alias)
+			}
+		}
+		merged.Tags[name] = kept
+	}
+
+	for name, aliases := range override.Tags {
+		merged.Tags[name] = append(merged.Tags[name], aliases...)
+	}
+
+	if override.SecondaryTags != nil {
+		merged.SecondaryTags = override.SecondaryTags
+	}
+	if override.Severity != nil {
+		merged.Severity = override.Severity
+	}
+	if override.DroppedEntries != nil {
+		merged.DroppedEntries = override.DroppedEntries
+	}
+
+	return merged
+}
+
+// loadConfig resolves the configuration for a changelog. With an explicit
+// configPath only that file is used; otherwise every .helmlog.yaml from the
+// repository root down to the changelog's directory is applied in order.
+func loadConfig(changelogPath, configPath string) (*changelogConfig, error) {
+	absChangelog, err := filepath.Abs(changelogPath)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to resolve %s: %w", changelogPath, err)
+	}
+
+	configPaths := []string{configPath}
+	if configPath == "" {
+		configPaths = discoverConfigFiles(filepath.Dir(absChangelog))
+	}
+
+	cfg := defaultConfigSettings
+	for _, path := range configPaths {
+		fileConfig, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg = mergeConfigSettings(cfg, fileConfig.configSettings)
+
+		absConfig, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: failed to resolve %s: %w", path, err)
+		}
+		for _, override := range fileConfig.Overrides {
+			if overrideApplies(override, filepath.Dir(absConfig), absChangelog) {
+				cfg = mergeConfigSettings(cfg, override.configSettings)
+			}
+		}
+	}
+
+	return buildConfig(cfg)
+}
+
+// discoverConfigFiles returns the .helmlog.yaml files between the repository
+// root (the first parent holding .git) and dir, outermost first.
+func discoverConfigFiles(dir string) []string {
+	found := []string{}
+	for {
+		candidate := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			found = append(found, candidate)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+
+	return found
+}
+
+func readConfigFile(path string) (helmlogConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return helmlogConfig{}, fmt.Errorf("ERROR: config file not found: %s", path)
+		}
+		return helmlogConfig{}, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+	}
+
+	// Unknown keys are errors so a misspelled rule or setting is not ignored
+	var cfg helmlogConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return helmlogConfig{}, fmt.Errorf("ERROR: failed to parse %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func overrideApplies(override configOverride, configDir, changelogPath string) bool {
+	for _, path := range override.Paths {
+		prefix := filepath.Join(configDir, filepath.FromSlash(path))
+		if changelogPath == prefix || strings.HasPrefix(changelogPath, prefix+string(os.PathSeparator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigMergesRootOverridesAndChartConfig(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("os.Mkdir() error = %v", err)
+	}
+	chartDir := filepath.Join(root, "logs", "chart")
+	if err := os.MkdirAll(chartDir, 0o755); err != nil {
+		t.Fatalf("os.MkdirAll() error = %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(root, configFileName), `
+tags:
+  Perf: [performance]
+overrides:
+  - paths: [logs]
+    tags:
+      Update: [upgrade]
+  - paths: [metrics]
+    tags:
+      Docs: [doc]
+`)
+	writeTestFile(t, filepath.Join(chartDir, configFileName), `
+tags:
+  Change: [improvement]
+dropped_entries:
+  - '^- \[Chore\] Bump version'
+`)
+
+	config, err := loadConfig(filepath.Join(chartDir, "CHANGELOG.md"), "")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	taxonomy := config.Taxonomy
+
+	for tag, want := range map[string]string{
+		"performance": "Perf",
+		"UPGRADE":     "Update",
+		"Improvement": "Change",
+		"feature":     "Feat",
+	} {
+		if err := taxonomy.ValidateTag(tag); err != nil {
+			t.Fatalf("ValidateTag(%q) error = %v", tag, err)
+		}
+		if got := taxonomy.NormalizeTag(tag); got != want {
+			t.Fatalf("NormalizeTag(%q) = %q, want %q", tag, got, want)
+		}
+	}
+
+	if err := taxonomy.ValidateTag("doc"); err == nil {
+		t.Fatalf("ValidateTag(doc) error = nil, want override for metrics only")
+	}
+
+	if !taxonomy.IsDropped("- [Chore] Bump version to 1.2.3") {
+		t.Fatalf("IsDropped() = false for configured pattern")
+	}
+	if taxonomy.IsDropped("- [Chore] Bump chart dependency to opentelemetry-collector 0.1.0") {
+		t.Fatalf("IsDropped() = true, want default pattern replaced")
+	}
+}
+
+func TestLoadConfigExplicitConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "custom.yaml")
+	writeTestFile(t, configPath, "severity: [Breaking, Fix, Feat]\n")
+
+	config, err := loadConfig(filepath.Join(dir, "CHANGELOG.md"), configPath)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	taxonomy := config.Taxonomy
+
+	if got := taxonomy.SelectPrimaryTag([]string{"Feat", "Fix", ":warning: Change"}); got != "Fix" {
+		t.Fatalf("SelectPrimaryTag() = %q, want %q", got, "Fix")
+	}
+	if got := defaultTaxonomy.SelectPrimaryTag([]string{"Feat", "Fix", ":warning: Change"}); got != "Fix" {
+		t.Fatalf("default SelectPrimaryTag() = %q, want last non-warning tag", got)
+	}
+	if got := taxonomy.SelectPrimaryTag([]string{"Fix", "Breaking"}); got != "Breaking" {
+		t.Fatalf("SelectPrimaryTag() = %q, want %q", got, "Breaking")
+	}
+	if got := defaultTaxonomy.SelectPrimaryTag([]string{"Breaking", "Fix"}); got != "Fix" {
+		t.Fatalf("default SelectPrimaryTag() = %q, want %q", got, "Fix")
+	}
+
+	if _, err := loadConfig(filepath.Join(dir, "CHANGELOG.md"), filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatalf("loadConfig() error = nil for missing config")
+	}
+}
+
+func TestLoadConfigRejectsInvalidDroppedPattern(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, configFileName)
+	writeTestFile(t, configPath, "dropped_entries: ['(']\n")
+
+	_, err := loadConfig(filepath.Join(dir, "CHANGELOG.md"), configPath)
+	if err == nil || !strings.Contains(err.Error(), "invalid dropped entry pattern") {
+		t.Fatalf("loadConfig() error = %v, want invalid pattern error", err)
+	}
+}
+
+func TestRunValidateUsesConfigNextToChangelog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, path, "### v1.0.0 / 2026-01-01\n- [UPGRADE] Bump fluent-bit\n")
+
+	if err := runValidate([]string{path}, validateOptions{}); err == nil {
+		t.Fatalf("runValidate() error = nil, want unknown tag without config")
+	}
+
+	writeTestFile(t, filepath.Join(dir, configFileName), "tags:\n  Update: [upgrade]\n")
+	if err := runValidate([]string{path}, validateOptions{}); err != nil {
+		t.Fatalf("runValidate() error = %v", err)
+	}
+
+	result, err := parseFile(path, parseOptions{})
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+	if got := result.Log.Releases[0].Entries[0].Tag; got != "Update" {
+		t.Fatalf("Tag = %q, want %q", got, "Update")
+	}
+}
+
+func TestRunValidateUsesConfiguredDialect(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, path, "### v0.0.2 / 2026-01-02\n- Untagged entry\n\n### v0.0.1 / 2026-01-01\n- [Feat] Initial release\n")
+
+	if err := runValidate([]string{path}, validateOptions{}); err == nil {
+		t.Fatalf("runValidate() error = nil, want malformed entry for the helm dialect")
+	}
+
+	writeTestFile(t, filepath.Join(dir, configFileName), "dialect: legacy\n")
+	if err := runValidate([]string{path}, validateOptions{}); err != nil {
+		t.Fatalf("runValidate() error = %v", err)
+	}
+
+	result, err := parseFile(path, parseOptions{})
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+	if result.Dialect != "legacy" {
+		t.Fatalf("Dialect = %q, want %q", result.Dialect, "legacy")
+	}
+
+	result, err = parseFile(path, parseOptions{Dialect: "helm"})
+	if err == nil {
+		t.Fatalf("parseFile(--dialect helm) dialect = %q, want malformed entry", result.Dialect)
+	}
+}
+
+func TestLoadConfigRejectsUnknownDialect(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, configFileName)
+	writeTestFile(t, configPath, "dialect: mkdocs\n")
+
+	_, err := loadConfig(filepath.Join(dir, "CHANGELOG.md"), configPath)
+	if err == nil || !strings.Contains(err.Error(), `unknown changelog dialect "mkdocs"`) {
+		t.Fatalf("loadConfig() error = %v, want unknown dialect error", err)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, configFileName)
+	writeTestFile(t, configPath, "overrides:\n  - paths: [logs]\n    exception:\n      duplicate-release: [v1.0.0]\n")
+
+	_, err := loadConfig(filepath.Join(dir, "CHANGELOG.md"), configPath)
+	if err == nil || !strings.Contains(err.Error(), "field exception not found") {
+		t.Fatalf("loadConfig() error = %v, want unknown key error", err)
+	}
+}
+
+func TestLoadConfigAcceptsEmptyFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, configFileName)
+	writeTestFile(t, configPath, "# nothing configured yet\n")
+
+	if _, err := loadConfig(filepath.Join(dir, "CHANGELOG.md"), configPath); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+const (
+	diagnosticFormatText   = "text"
+	diagnosticFormatJSON   = "json"
+	diagnosticFormatSARIF  = "sarif"
+	diagnosticFormatGitHub = "github"
+)
+
+type jsonDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Release  string `json:"release,omitempty"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+type jsonDiagnosticReport struct {
+	Files       int              `json:"files"`
+	Diagnostics []jsonDiagnostic `json:"diagnostics"`
+}
+
+func validateDiagnosticFormat(format string) error {
+	switch format {
+	case diagnosticFormatText, diagnosticFormatJSON, diagnosticFormatSARIF, diagnosticFormatGitHub:
+		return nil
+	default:
+		return fmt.Errorf("ERROR: unknown output format %q (expected text, json, sarif or github)", format)
+	}
+}
+
+// writeDiagnostics prints diagnostics in a machine-readable format. The text
+// format is handled by the caller through the returned error.
+func writeDiagnostics(w io.Writer, format string, files int, diagnostics []helmlog.Diagnostic) error {
+	switch format {
+	case diagnosticFormatJSON:
+		return writeJSONDiagnostics(w, files, diagnostics)
+	case diagnosticFormatSARIF:
+		return writeSARIFDiagnostics(w, diagnostics)
+	case diagnosticFormatGitHub:
+		return writeGitHubDiagnostics(w, diagnostics)
+	default:
+		return nil
+	}
+}
+
+func writeJSONDiagnostics(w io.Writer, files int, diagnostics []helmlog.Diagnostic) error {
+	report := jsonDiagnosticReport{Files: files, Diagnostics: make([]jsonDiagnostic, 0, len(diagnostics))}
+	for _, d := range diagnostics {
+		report.Diagnostics = append(report.Diagnostics, jsonDiagnostic{
+			File:     d.Path,
+			Line:     d.LineNumber,
+			Column:   d.Column,
+			Rule:     d.Rule,
+			Severity: "error",
+			Message:  d.Reason,
+			Release:  d.ReleaseVersion,
+			Fix:      d.Fix,
+		})
+	}
+
+	return writeIndentedJSON(w, report)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+func writeSARIFDiagnostics(w io.Writer, diagnostics []helmlog.Diagnostic) error {
+	ruleIDs := helmlog.RuleIDs()
+	rules := make([]sarifRule, 0, len(ruleIDs))
+	for _, id := range ruleIDs {
+		description, _ := helmlog.RuleDescription(id)
+		rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}})
+	}
+
+	results := make([]sarifResult, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		result := sarifResult{
+			RuleID:  d.Rule,
+			Level:   "error",
+			Message: sarifMessage{Text: d.Reason},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: d.Path},
+					Region:           sarifRegion{StartLine: max(d.LineNumber, 1), StartColumn: max(d.Column, 1)},
+				},
+			}},
+		}
+		if d.Fix != "" {
+			result.Properties = map[string]string{"suggestedFix": d.Fix}
+		}
+		results = append(results, result)
+	}
+
+	return writeIndentedJSON(w, sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "helmlog", Rules: rules}},
+			Results: results,
+		}},
+	})
+}
+
+// writeGitHubDiagnostics prints GitHub Actions workflow commands so problems
+// are annotated inline on pull request diffs.
+func writeGitHubDiagnostics(w io.Writer, diagnostics []helmlog.Diagnostic) error {
+	for _, d := range diagnostics {
+		message := d.Reason
+		if d.Fix != "" {
+			message += ". Fix: " + d.Fix
+		}
+
+		_, err := fmt.Fprintf(w, "::error file=%s,line=%d,col=%d,title=%s::%s\n",
+			escapeGitHubProperty(d.Path),
+			max(d.LineNumber, 1),
+			max(d.Column, 1),
+			escapeGitHubProperty("helmlog "+d.Rule),
+			escapeGitHubData(message),
+		)
+		if err != nil {
+			return fmt.Errorf("ERROR: failed to write output: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func escapeGitHubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+func escapeGitHubProperty(value string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeGitHubData(value))
+}
+
+func writeIndentedJSON(w io.Writer, value any) error {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to encode json: %w", err)
+	}
+
+	output = append(output, '\n')
+	if _, err := w.Write(output); err != nil {
+		return fmt.Errorf("ERROR: failed to write output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+const diagnosticsTestChangelog = `### v1.2.3 / 2026-02-10
+- [Improvment] Reduce memory usage
+  - nested detail of a rejected entry
+- missing tag
+- [Fix] Valid entry
+
+## v1.2.2 - 2026-01-01
+### v1.2 / 2026-01-01
+- [Feat] Another valid entry
+`
+
+func TestParseMarkdownDiagnosticsCollectsEveryViolation(t *testing.T) {
+	result, diagnostics, err := parseMarkdownDiagnostics(strings.NewReader(diagnosticsTestChangelog), parseOptions{Dialect: helmlog.DialectHelm})
+	if err != nil {
+		t.Fatalf("parseMarkdownDiagnostics() error = %v", err)
+	}
+
+	type location struct {
+		Line   int
+		Column int
+		Rule   string
+	}
+	got := make([]location, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		got = append(got, location{Line: d.LineNumber, Column: d.Column, Rule: d.Rule})
+	}
+
+	want := []location{
+		{Line: 2, Column: 3, Rule: helmlog.RuleUnknownTag},
+		{Line: 4, Column: 1, Rule: helmlog.RuleMalformedEntry},
+		{Line: 8, Column: 1, Rule: helmlog.RuleInvalidReleaseHeader},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diagnostics = %#v, want %#v", got, want)
+	}
+
+	if got := diagnostics[0].Fix; !strings.Contains(got, "allowed tags") {
+		t.Fatalf("unknown tag fix = %q", got)
+	}
+
+	if result.EntryCount != 2 {
+		t.Fatalf("EntryCount = %d, want 2", result.EntryCount)
+	}
+	if got := result.Log.Releases[0].Entries[0].Text; got != "Valid entry" {
+		t.Fatalf("first kept entry = %q, want %q", got, "Valid entry")
+	}
+}
+
+func TestRunValidateReportsAllFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.md")
+	second := filepath.Join(dir, "second.md")
+	writeTestFile(t, first, "### v1.0.0 / 2026-01-01\n- [Nope] one\n")
+	writeTestFile(t, second, "### v1.0.0 / 2026-01-01\n- [Nope] two\n- [Nope] three\n")
+
+	err := runValidate([]string{first, second}, validateOptions{})
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{first + ":2:3", second + ":2:3", second + ":3:3", "3 problem(s) found in 2 changelog file(s)"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("error = %q, missing %q", msg, want)
+		}
+	}
+}
+
+func TestRunValidateContinuesAfterUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing", "CHANGELOG.md")
+	misconfigured := filepath.Join(dir, "misconfigured", "CHANGELOG.md")
+	invalid := filepath.Join(dir, "invalid", "CHANGELOG.md")
+	writeTestFile(t, misconfigured, "### v1.0.0 / 2026-01-01\n- [Fix] fine\n")
+	writeTestFile(t, filepath.Join(dir, "misconfigured", configFileName), "rules:\n  no-such-rule: false\n")
+	writeTestFile(t, invalid, "### v1.0.0 / 2026-01-01\n- [Nope] one\n")
+
+	err := runValidate([]string{missing, misconfigured, invalid}, validateOptions{})
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{missing + ": failed to open", misconfigured + `: unknown rule "no-such-rule"`, invalid + ":2:3", "3 problem(s) found in 3 changelog file(s)"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("error = %q, missing %q", msg, want)
+		}
+	}
+}
+
+func TestWriteDiagnosticsFormats(t *testing.T) {
+	diagnostics := []helmlog.Diagnostic{{
+		Path:           "otel/CHANGELOG.md",
+		LineNumber:     4,
+		Column:         3,
+		Rule:           helmlog.RuleUnknownTag,
+		Reason:         `unknown tag "[DOC]"`,
+		ReleaseVersion: "v0.0.1",
+		Fix:            `replace with "[Docs]"`,
+	}}
+
+	var github bytes.Buffer
+	if err := writeDiagnostics(&github, diagnosticFormatGitHub, 1, diagnostics); err != nil {
+		t.Fatalf("writeDiagnostics(github) error = %v", err)
+	}
+	wantGitHub := "::error file=otel/CHANGELOG.md,line=4,col=3,title=helmlog unknown-tag::unknown tag \"[DOC]\". Fix: replace with \"[Docs]\"\n"
+	if github.String() != wantGitHub {
+		t.Fatalf("github output = %q, want %q", github.String(), wantGitHub)
+	}
+
+	var jsonOutput bytes.Buffer
+	if err := writeDiagnostics(&jsonOutput, diagnosticFormatJSON, 1, diagnostics); err != nil {
+		t.Fatalf("writeDiagnostics(json) error = %v", err)
+	}
+	var report jsonDiagnosticReport
+	if err := json.Unmarshal(jsonOutput.Bytes(), &report); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	wantJSON := jsonDiagnostic{File: "otel/CHANGELOG.md", Line: 4, Column: 3, Rule: helmlog.RuleUnknownTag, Severity: "error", Message: `unknown tag "[DOC]"`, Release: "v0.0.1", Fix: `replace with "[Docs]"`}
+	if len(report.Diagnostics) != 1 || report.Diagnostics[0] != wantJSON {
+		t.Fatalf("json diagnostics = %#v, want %#v", report.Diagnostics, wantJSON)
+	}
+
+	var sarif bytes.Buffer
+	if err := writeDiagnostics(&sarif, diagnosticFormatSARIF, 1, diagnostics); err != nil {
+		t.Fatalf("writeDiagnostics(sarif) error = %v", err)
+	}
+	var decoded sarifLog
+	if err := json.Unmarshal(sarif.Bytes(), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal(sarif) error = %v", err)
+	}
+	result := decoded.Runs[0].Results[0]
+	if result.RuleID != helmlog.RuleUnknownTag || result.Locations[0].PhysicalLocation.Region.StartLine != 4 {
+		t.Fatalf("sarif result = %#v", result)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("os.MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+// resolveDialect is helmlog.ResolveDialect with the CLI error prefix.
+func resolveDialect(name string, lines []string) (*helmlog.Dialect, error) {
+	dialect, err := helmlog.ResolveDialect(name, lines)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %w", err)
+	}
+
+	return dialect, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+func TestParseMarkdownSupervisedDialect(t *testing.T) {
+	input := `# Changelog
+
+## v0.11.0 - 2026-07-15
+
+- [chore] Bump Supervisor to version 0.155.1.
+- [feat] Align with the 0.155.1 supervised image release.
+
+## v0.10.0 - 2026-07-07
+
+- [chore] Bump Supervisor to version 0.155.0.
+`
+
+	result, err := parseMarkdown(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	if result.Dialect != helmlog.DialectSupervised {
+		t.Fatalf("Dialect = %q, want %q", result.Dialect, helmlog.DialectSupervised)
+	}
+	if result.ReleaseCount != 2 || result.EntryCount != 3 {
+		t.Fatalf("ReleaseCount, EntryCount = %d, %d, want 2, 3", result.ReleaseCount, result.EntryCount)
+	}
+	if got := result.Log.Releases[0].Entries[1].Tag; got != "Feat" {
+		t.Fatalf("tag = %q, want %q", got, "Feat")
+	}
+}
+
+func TestParseMarkdownSupervisedDialectInvalidHeader(t *testing.T) {
+	input := `## v0.11.0 - 2026-07-15
+- [chore] Bump Supervisor to version 0.155.1.
+
+## v0.10 - 2026-07-07
+- [chore] Bump Supervisor to version 0.155.0.
+`
+
+	_, err := parseMarkdown(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected parse error, got nil")
+	}
+	if !strings.Contains(err.Error(), `release header must match "## vX.Y.Z - YYYY-MM-DD"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseMarkdownKeepAChangelogDialect(t *testing.T) {
+	input := `# Changelog
+
+## [Unreleased]
+
+### Added
+- Not released yet
+
+## [0.1.6] - 2026-02-02
+
+### Added
+- Service discovery support
+  - Support for PostgreSQL credentials
+- Installation summary file
+
+### Fixed
+- Add user to systemd-journal group
+
+### Supported Platforms
+- Linux (x86_64, arm64) - systemd service
+  - launchd on macOS
+`
+
+	result, err := parseMarkdown(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	if result.ReleaseCount != 1 || result.EntryCount != 3 {
+		t.Fatalf("ReleaseCount, EntryCount = %d, %d, want 1, 3", result.ReleaseCount, result.EntryCount)
+	}
+
+	release := result.Log.Releases[0]
+	if release.Version != "0.1.6" {
+		t.Fatalf("version = %q, want %q", release.Version, "0.1.6")
+	}
+
+	wantTags := []string{"Feat", "Feat", "Fix"}
+	for i, want := range wantTags {
+		if got := release.Entries[i].Tag; got != want {
+			t.Fatalf("entry %d tag = %q, want %q", i, got, want)
+		}
+	}
+
+	if got, want := release.Entries[0].Text, "Service discovery support\n- Support for PostgreSQL credentials"; got != want {
+		t.Fatalf("entry text = %q, want %q", got, want)
+	}
+}
+
+func TestParseMarkdownLegacyDialect(t *testing.T) {
+	input := `# Changelog
+
+## Fluentd
+
+### v1.18.0-4 / 2025-4-10
+* [CHANGE] Update the coralogix API
+
+### 0.0.1 / 2024-11-1
+
+* Initial release
+`
+
+	result, err := parseMarkdown(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	if result.Dialect != helmlog.DialectLegacy {
+		t.Fatalf("Dialect = %q, want %q", result.Dialect, helmlog.DialectLegacy)
+	}
+
+	first := result.Log.Releases[0]
+	if first.Version != "v1.18.0-4" || first.Date != "2025-04-10" {
+		t.Fatalf("first release = %s / %s, want v1.18.0-4 / 2025-04-10", first.Version, first.Date)
+	}
+
+	second := result.Log.Releases[1]
+	if second.Date != "2024-11-01" {
+		t.Fatalf("second release date = %q, want %q", second.Date, "2024-11-01")
+	}
+	if got := second.Entries[0].Tag; got != "Change" {
+		t.Fatalf("untagged entry tag = %q, want %q", got, "Change")
+	}
+}
+
+func TestParseMarkdownUntaggedEntryStaysHelm(t *testing.T) {
+	input := `## otel-integration
+
+### v0.0.2 / 2026-01-02
+- [Fix] Tagged entry
+- missing tag here
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`
+
+	result, diagnostics, err := parseMarkdownDiagnostics(strings.NewReader(input), parseOptions{})
+	if err != nil {
+		t.Fatalf("parseMarkdownDiagnostics() error = %v", err)
+	}
+
+	if result.Dialect != helmlog.DialectHelm {
+		t.Fatalf("Dialect = %q, want %q", result.Dialect, helmlog.DialectHelm)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Rule != helmlog.RuleMalformedEntry || diagnostics[0].LineNumber != 5 {
+		t.Fatalf("diagnostics = %+v, want one %s on line 5", diagnostics, helmlog.RuleMalformedEntry)
+	}
+}
+
+func TestParseMarkdownExplicitDialectOverridesDetection(t *testing.T) {
+	input := `### v1.2.3 / 2026-02-10
+* [Fix] Rejected by the helm dialect
+- [Feat] Kept by the helm dialect
+`
+
+	// The legacy dialect would accept the "* " bullet
+	result, diagnostics, err := parseMarkdownDiagnostics(strings.NewReader(input), parseOptions{Dialect: helmlog.DialectHelm})
+	if err != nil {
+		t.Fatalf("parseMarkdownDiagnostics() error = %v", err)
+	}
+
+	if len(diagnostics) != 1 || diagnostics[0].Rule != helmlog.RuleMalformedEntry || diagnostics[0].LineNumber != 2 {
+		t.Fatalf("diagnostics = %+v, want one %s on line 2", diagnostics, helmlog.RuleMalformedEntry)
+	}
+	if want := `replace "* " with "- "`; diagnostics[0].Fix != want {
+		t.Fatalf("Fix = %q, want %q", diagnostics[0].Fix, want)
+	}
+	if result.EntryCount != 1 {
+		t.Fatalf("EntryCount = %d, want 1", result.EntryCount)
+	}
+	if got := result.Log.Releases[0].Entries[0].Tag; got != "Feat" {
+		t.Fatalf("tag = %q, want %q", got, "Feat")
+	}
+}
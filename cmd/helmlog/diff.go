@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+const (
+	diffFormatMarkdown = "markdown"
+	diffFormatJSON     = "json"
+)
+
+type diffOptions struct {
+	parseOptions
+	From    string
+	To      string
+	FromRef string
+	ToRef   string
+	Format  string
+}
+
+// changelogDiff is the set of entries added between two versions or two
+// revisions of a changelog, grouped by tag.
+type changelogDiff struct {
+	From       string         `json:"from,omitempty"`
+	To         string         `json:"to,omitempty"`
+	Releases   []string       `json:"releases"`
+	Highlights []diffEntry    `json:"highlights"`
+	Groups     []diffTagGroup `json:"groups"`
+}
+
+type diffTagGroup struct {
+	Tag     string      `json:"tag"`
+	Entries []diffEntry `json:"entries"`
+}
+
+type diffEntry struct {
+	Version string `json:"version"`
+	helmlog.Entry
+}
+
+func newDiffCmd() *cobra.Command {
+	outputPath := ""
+	opts := diffOptions{Format: diffFormatMarkdown}
+
+	cmd := &cobra.Command{
+		Use:   "diff <CHANGELOG.md>",
+		Short: "Show what changed between two chart versions or git refs",
+		Long: `Show the changelog entries added between two versions, grouped by tag.
+
+--from and --to select the releases with from < version <= to. --from-ref and
+--to-ref compare the changelog at two git revisions instead and report the
+entries present at --to-ref only; --to-ref defaults to the working tree. Both
+kinds of bounds can be combined.
+
+[Breaking] entries and entries qualified with :warning: are listed again at the
+top and marked in their tag group.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runDiff(args[0], outputPath, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().StringVar(&opts.From, "from", "", "Exclusive lower bound of the version range")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Inclusive upper bound of the version range")
+	cmd.Flags().StringVar(&opts.FromRef, "from-ref", "", "Git ref of the changelog to compare from")
+	cmd.Flags().StringVar(&opts.ToRef, "to-ref", "", "Git ref of the changelog to compare to (defaults to the working tree)")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", diffFormatMarkdown, "Output format (markdown, json)")
+	addParseFlags(cmd, &opts.parseOptions)
+
+	return cmd
+}
+
+func runDiff(path, outputPath string, opts diffOptions) error {
+	if opts.Format != diffFormatMarkdown && opts.Format != diffFormatJSON {
+		return fmt.Errorf("ERROR: unknown diff format %q (expected markdown or json)", opts.Format)
+	}
+	if opts.From == "" && opts.To == "" && opts.FromRef == "" && opts.ToRef == "" {
+		return errors.New("ERROR: diff needs --from, --to, --from-ref or --to-ref")
+	}
+
+	diff, err := buildChangelogDiff(path, opts)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	if opts.Format == diffFormatJSON {
+		output, err = json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("ERROR: failed to encode json: %w", err)
+		}
+		output = append(output, '\n')
+	} else {
+		output = []byte(renderDiffMarkdown(diff))
+	}
+
+	if outputPath == "" || outputPath == "-" {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("ERROR: failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, output, 0o644); err != nil {
+		return fmt.Errorf("ERROR: failed to write %s: %w", outputPath, err)
+	}
+
+	fmt.Printf("Wrote %s\n", outputPath)
+	return nil
+}
+
+func buildChangelogDiff(path string, opts diffOptions) (changelogDiff, error) {
+	var to helmlog.Changelog
+	var err error
+	if opts.ToRef != "" {
+		to, err = loadChangelogAtRef(path, opts.ToRef, opts.parseOptions)
+	} else {
+		to, err = loadChangelog(path, opts.parseOptions)
+	}
+	if err != nil {
+		return changelogDiff{}, err
+	}
+
+	releases, err := releasesInRange(to.Releases, opts.From, opts.To)
+	if err != nil {
+		return changelogDiff{}, err
+	}
+
+	if opts.FromRef != "" {
+		from, err := loadChangelogAtRef(path, opts.FromRef, opts.parseOptions)
+		if err != nil {
+			return changelogDiff{}, err
+		}
+		releases = newEntries(releases, from.Releases)
+	}
+
+	diff := changelogDiff{
+		From:       firstNonEmpty(opts.From, opts.FromRef),
+		To:         firstNonEmpty(opts.To, opts.ToRef),
+		Releases:   []string{},
+		Highlights: []diffEntry{},
+		Groups:     []diffTagGroup{},
+	}
+
+	byTag := map[string][]helmlog.Entry{}
+	versions := map[string][]diffEntry{}
+	for _, release := range releases {
+		diff.Releases = append(diff.Releases, release.Version)
+		for _, entry := range release.Entries {
+			item := diffEntry{Version: release.Version, Entry: entry}
+			if isHighlighted(entry) {
+				diff.Highlights = append(diff.Highlights, item)
+			}
+			byTag[entry.Tag] = append(byTag[entry.Tag], entry)
+			versions[entry.Tag] = append(versions[entry.Tag], item)
+		}
+	}
+
+	for _, tag := range orderedTags(byTag) {
+		diff.Groups = append(diff.Groups, diffTagGroup{Tag: tag, Entries: versions[tag]})
+	}
+
+	return diff, nil
+}
+
+// loadChangelogAtRef parses the changelog as it was at ref. Older revisions
+// may predate the current validation rules, so entries that no longer
+// validate are skipped rather than failing the diff.
+func loadChangelogAtRef(path, ref string, opts parseOptions) (helmlog.Changelog, error) {
+	repoRoot, err := gitRepoRoot()
+	if err != nil {
+		return helmlog.Changelog{}, err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return helmlog.Changelog{}, fmt.Errorf("ERROR: failed to resolve %s: %w", path, err)
+	}
+
+	relativePath, err := repoRelativeChartFile(repoRoot, absPath)
+	if err != nil {
+		return helmlog.Changelog{}, err
+	}
+
+	content, err := runGitCommand(repoRoot, "show", ref+":"+filepath.ToSlash(relativePath))
+	if err != nil {
+		return helmlog.Changelog{}, fmt.Errorf("ERROR: failed to read %s at %s: %w", relativePath, ref, err)
+	}
+
+	opts, err = opts.withConfig(path)
+	if err != nil {
+		return helmlog.Changelog{}, err
+	}
+	opts.revision = ref
+
+	result, _, err := parseMarkdownDiagnostics(strings.NewReader(content), opts)
+	if err != nil {
+		return helmlog.Changelog{}, err
+	}
+
+	helmlog.Sort(&result.Log)
+	return result.Log, nil
+}
+
+// newEntries keeps the entries of releases that base does not have. Releases
+// are matched by version and entries by tag and text, so an entry added to an
+// existing release is reported too.
+func newEntries(releases, base []helmlog.Release) []helmlog.Release {
+	known := map[string]map[string]bool{}
+	for _, release := range base {
+		version := trimVersionPrefix(release.Version)
+		if known[version] == nil {
+			known[version] = map[string]bool{}
+		}
+		for _, entry := range release.Entries {
+			known[version][entry.Tag+"\x00"+entry.Text] = true
+		}
+	}
+
+	out := make([]helmlog.Release, 0, len(releases))
+	for _, release := range releases {
+		entries := make([]helmlog.Entry, 0, len(release.Entries))
+		for _, entry := range release.Entries {
+			if !known[trimVersionPrefix(release.Version)][entry.Tag+"\x00"+entry.Text] {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		release.Entries = entries
+		release.Upstream = nil
+		out = append(out, release)
+	}
+
+	return out
+}
+
+func renderDiffMarkdown(diff changelogDiff) string {
+	var b strings.Builder
+
+	switch {
+	case diff.From != "" && diff.To != "":
+		fmt.Fprintf(&b, "## Changes from %s to %s\n", diff.From, diff.To)
+	case diff.From != "":
+		fmt.Fprintf(&b, "## Changes since %s\n", diff.From)
+	default:
+		fmt.Fprintf(&b, "## Changes up to %s\n", diff.To)
+	}
+
+	if len(diff.Releases) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	if len(diff.Highlights) > 0 {
+		b.WriteString("\n### :warning: Breaking changes and warnings\n\n")
+		for _, item := range diff.Highlights {
+			b.WriteString(diffBullet(item, "["+item.Tag+"] ") + "\n")
+		}
+	}
+
+	for _, group := range diff.Groups {
+		b.WriteString("\n### " + group.Tag + "\n\n")
+		for _, item := range group.Entries {
+			prefix := ""
+			if isHighlighted(item.Entry) && group.Tag != "Breaking" {
+				prefix = ":warning: "
+			}
+			b.WriteString(diffBullet(item, prefix) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// isHighlighted reports entries a reader must not miss when upgrading. The tag
+// is checked as well for artifacts generated before Entry.Breaking existed.
+func isHighlighted(entry helmlog.Entry) bool {
+	return entry.Breaking || entry.Warning || entry.Tag == "Breaking"
+}
+
+func diffBullet(item diffEntry, prefix string) string {
+	lines := entryLines(item.Entry)
+
+	var b strings.Builder
+	b.WriteString("- " + prefix + lines[0] + " (" + item.Version + ")")
+	for _, sub := range lines[1:] {
+		b.WriteString("\n  - " + sub)
+	}
+
+	return b.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const diffTestChangelog = `# Changelog
+
+### v0.0.4 / 2026-01-04
+- [Fix] Fix exporter retries
+
+### v0.0.3 / 2026-01-03
+- [Breaking] Drop the legacy logs pipeline
+- [:warning: Change][Feat] Enable batching by default
+- [Feat] Add host metrics preset
+
+### v0.0.2 / 2026-01-02
+- [Fix] Fix typo in values
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`
+
+// initialDevelopmentConfig lets the 0.0.z fixtures carry breaking changes.
+const initialDevelopmentConfig = "initial_development: true\n"
+
+func TestBuildChangelogDiffVersionRange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, path, diffTestChangelog)
+	writeTestFile(t, filepath.Join(dir, configFileName), initialDevelopmentConfig)
+
+	diff, err := buildChangelogDiff(path, diffOptions{From: "v0.0.2", To: "0.0.3"})
+	if err != nil {
+		t.Fatalf("buildChangelogDiff() error = %v", err)
+	}
+
+	if want := []string{"v0.0.3"}; !reflect.DeepEqual(diff.Releases, want) {
+		t.Fatalf("Releases = %#v, want %#v", diff.Releases, want)
+	}
+
+	highlights := []string{}
+	for _, item := range diff.Highlights {
+		highlights = append(highlights, item.Tag+": "+item.Text)
+	}
+	wantHighlights := []string{"Breaking: Drop the legacy logs pipeline", "Feat: Enable batching by default"}
+	if !reflect.DeepEqual(highlights, wantHighlights) {
+		t.Fatalf("highlights = %#v, want %#v", highlights, wantHighlights)
+	}
+
+	got := renderDiffMarkdown(diff)
+	want := `## Changes from v0.0.2 to 0.0.3
+
+### :warning: Breaking changes and warnings
+
+- [Breaking] Drop the legacy logs pipeline (v0.0.3)
+- [Feat] Enable batching by default (v0.0.3)
+
+### Breaking
+
+- Drop the legacy logs pipeline (v0.0.3)
+
+### Feat
+
+- :warning: Enable batching by default (v0.0.3)
+- Add host metrics preset (v0.0.3)
+`
+	if got != want {
+		t.Fatalf("renderDiffMarkdown() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildChangelogDiffRefs(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
+	t.Chdir(repoRoot)
+
+	path := filepath.Join("otel-linux-standalone", "CHANGELOG.md")
+	writeTestFile(t, filepath.Join("otel-linux-standalone", configFileName), initialDevelopmentConfig)
+	base := strings.SplitN(diffTestChangelog, "### v0.0.4", 2)
+	writeTestFile(t, path, "# Changelog\n\n### v0.0.4"+strings.Replace(base[1], "- [Feat] Add host metrics preset\n", "", 1))
+	runTestGit(t, repoRoot, "add", path)
+	runTestGit(t, repoRoot, "commit", "-m", "changelog base")
+
+	writeTestFile(t, path, strings.Replace(diffTestChangelog, "# Changelog\n", "# Changelog\n\n### v0.0.5 / 2026-01-05\n- [Chore] Update docs\n", 1))
+	runTestGit(t, repoRoot, "commit", "-am", "changelog update")
+
+	diff, err := buildChangelogDiff(path, diffOptions{FromRef: "HEAD~1", ToRef: "HEAD"})
+	if err != nil {
+		t.Fatalf("buildChangelogDiff() error = %v", err)
+	}
+
+	entries := []string{}
+	for _, group := range diff.Groups {
+		for _, item := range group.Entries {
+			entries = append(entries, item.Version+" "+group.Tag+": "+item.Text)
+		}
+	}
+	wantEntries := []string{"v0.0.3 Feat: Add host metrics preset", "v0.0.5 Chore: Update docs"}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Fatalf("entries = %#v, want %#v", entries, wantEntries)
+	}
+	if want := []string{"v0.0.5", "v0.0.3"}; !reflect.DeepEqual(diff.Releases, want) {
+		t.Fatalf("Releases = %#v, want %#v", diff.Releases, want)
+	}
+
+	// Without --to-ref the working tree is compared, which matches HEAD.
+	diff, err = buildChangelogDiff(path, diffOptions{FromRef: "HEAD"})
+	if err != nil {
+		t.Fatalf("buildChangelogDiff() error = %v", err)
+	}
+	if got := renderDiffMarkdown(diff); got != "## Changes since HEAD\n\nNo changes.\n" {
+		t.Fatalf("renderDiffMarkdown() = %q, want no changes", got)
+	}
+}
+
+func TestRunDiffJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	outputPath := filepath.Join(dir, "diff.json")
+	writeTestFile(t, path, diffTestChangelog)
+	writeTestFile(t, filepath.Join(dir, configFileName), initialDevelopmentConfig)
+
+	if err := runDiff(path, outputPath, diffOptions{From: "v0.0.3", Format: diffFormatJSON}); err != nil {
+		t.Fatalf("runDiff() error = %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+
+	var diff changelogDiff
+	if err := json.Unmarshal(content, &diff); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v\n%s", err, content)
+	}
+	if diff.From != "v0.0.3" || len(diff.Groups) != 1 || diff.Groups[0].Tag != "Fix" || diff.Groups[0].Entries[0].Version != "v0.0.4" {
+		t.Fatalf("diff = %#v", diff)
+	}
+	if len(diff.Highlights) != 0 {
+		t.Fatalf("Highlights = %#v, want none", diff.Highlights)
+	}
+}
+
+func TestRunDiffRequiresBounds(t *testing.T) {
+	err := runDiff("CHANGELOG.md", "", diffOptions{Format: diffFormatMarkdown})
+	if err == nil || !strings.Contains(err.Error(), "diff needs --from, --to, --from-ref or --to-ref") {
+		t.Fatalf("runDiff() error = %v, want missing bounds error", err)
+	}
+
+	err = runDiff("CHANGELOG.md", "", diffOptions{From: "v0.0.1", Format: "html"})
+	if err == nil || !strings.Contains(err.Error(), "unknown diff format") {
+		t.Fatalf("runDiff() error = %v, want unknown format error", err)
+	}
+}
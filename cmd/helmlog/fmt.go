@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+type fmtOptions struct {
+	parseOptions
+	Check bool
+	Write bool
+}
+
+// changelogBlock is a run of lines that moves as a unit when releases are
+// reordered. Blocks without a release are group headings such as
+// "## Fluentd" and are never moved.
+type changelogBlock struct {
+	Release *helmlog.Release
+	Lines   []string
+}
+
+func newFmtCmd() *cobra.Command {
+	opts := fmtOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "fmt <CHANGELOG.md> [<CHANGELOG.md> ...]",
+		Short: "Rewrite changelogs in canonical form",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runFmt(args, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "Print a unified diff and fail if any file is not formatted")
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "Write the result back to the source file")
+	cmd.MarkFlagsMutuallyExclusive("check", "write")
+	addParseFlags(cmd, &opts.parseOptions)
+
+	return cmd
+}
+
+func runFmt(args []string, opts fmtOptions) error {
+	unformatted := 0
+
+	for _, path := range args {
+		original, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+		}
+
+		parseOpts, err := opts.withConfig(path)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := formatChangelog(original, parseOpts)
+		if err != nil {
+			return fmt.Errorf("%w (%s)", err, path)
+		}
+
+		switch {
+		case opts.Check:
+			if bytes.Equal(original, formatted) {
+				continue
+			}
+
+			unformatted++
+			diff, err := unifiedDiff(path, original, formatted)
+			if err != nil {
+				return err
+			}
+			fmt.Print(diff)
+		case opts.Write:
+			if bytes.Equal(original, formatted) {
+				continue
+			}
+
+			if err := os.WriteFile(path, formatted, 0o644); err != nil {
+				return fmt.Errorf("ERROR: failed to write %s: %w", path, err)
+			}
+			fmt.Printf("Formatted %s\n", path)
+		default:
+			if _, err := os.Stdout.Write(formatted); err != nil {
+				return fmt.Errorf("ERROR: failed to write output: %w", err)
+			}
+		}
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("ERROR: %d changelog file(s) need formatting, run helmlog fmt --write", unformatted)
+	}
+
+	return nil
+}
+
+func unifiedDiff(path string, original, formatted []byte) (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(original)),
+		B:        difflib.SplitLines(string(formatted)),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("ERROR: failed to diff %s: %w", path, err)
+	}
+
+	return diff, nil
+}
+
+// formatChangelog normalizes tags, bullets, dates and blank lines and orders
+// releases by version, highest first, unless the release-order rule is off.
+// Lines it does not understand are kept verbatim.
+func formatChangelog(content []byte, opts parseOptions) ([]byte, error) {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	dialect, err := resolveDialect(opts.dialectName(), lines)
+	if err != nil {
+		return nil, err
+	}
+
+	preamble, blocks := splitChangelogBlocks(formatLines(lines, dialect, opts.tagTaxonomy()), dialect)
+	if opts.ruleEnabled(helmlog.RuleReleaseOrder) {
+		sortChangelogBlocks(blocks)
+	}
+
+	sections := make([]string, 0, len(blocks)+1)
+	if body := joinTrimmed(preamble); body != "" {
+		sections = append(sections, body)
+	}
+	for _, block := range blocks {
+		sections = append(sections, joinTrimmed(block.Lines))
+	}
+
+	if len(sections) == 0 {
+		return nil, errors.New("ERROR: changelog is empty")
+	}
+
+	return []byte(strings.Join(sections, "\n\n") + "\n"), nil
+}
+
+// formatLines rewrites individual lines and collapses blank-line runs. Fenced
+// code blocks are copied unchanged.
+func formatLines(lines []string, dialect *helmlog.Dialect, taxonomy *helmlog.Taxonomy) []string {
+	out := make([]string, 0, len(lines))
+	inFence := false
+	previousBlank := true
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			out = append(out, line)
+			previousBlank = false
+			continue
+		}
+
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		if trimmed == "" {
+			if !previousBlank {
+				out = append(out, "")
+			}
+			previousBlank = true
+			continue
+		}
+		previousBlank = false
+
+		if header, ok := dialect.FormatHeader(trimmed); ok {
+			out = append(out, header)
+			continue
+		}
+
+		out = append(out, formatBulletLine(line, dialect, taxonomy))
+	}
+
+	return out
+}
+
+func formatBulletLine(line string, dialect *helmlog.Dialect, taxonomy *helmlog.Taxonomy) string {
+	indentWidth := len(line) - len(strings.TrimLeft(line, " \t"))
+	indent := line[:indentWidth]
+	rest := strings.TrimRight(line[indentWidth:], " \t")
+
+	switch {
+	case strings.HasPrefix(rest, "* "):
+		rest = "- " + strings.TrimLeft(strings.TrimPrefix(rest, "* "), " ")
+	case strings.HasPrefix(rest, "- "):
+		rest = "- " + strings.TrimLeft(strings.TrimPrefix(rest, "- "), " ")
+	default:
+		return line
+	}
+
+	if indentWidth == 0 && dialect.SectionTags == nil {
+		rest = "- " + normalizeEntryTags(strings.TrimPrefix(rest, "- "), taxonomy)
+	}
+
+	return indent + rest
+}
+
+// normalizeEntryTags rewrites the leading "[Tag]" groups of an entry to their
+// canonical spelling, keeping the text between them untouched.
+func normalizeEntryTags(text string, taxonomy *helmlog.Taxonomy) string {
+	var b strings.Builder
+	rest := text
+	for {
+		trimmed := strings.TrimLeft(rest, " ")
+		if !strings.HasPrefix(trimmed, "[") {
+			break
+		}
+
+		end := strings.IndexByte(trimmed, ']')
+		if end <= 1 {
+			break
+		}
+
+		b.WriteString(rest[:len(rest)-len(trimmed)])
+		b.WriteString("[" + canonicalTagSpelling(trimmed[1:end], taxonomy) + "]")
+		rest = trimmed[end+1:]
+	}
+
+	return b.String() + rest
+}
+
+// canonicalTagSpelling fixes the case of a known tag without resolving
+// aliases: "[CHORE]" becomes "[Chore]" but "[Feature]" stays an alias of
+// "[Feat]". Unknown tags are left alone so validate can still report them.
+func canonicalTagSpelling(tag string, taxonomy *helmlog.Taxonomy) string {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if !taxonomy.IsAllowed(normalized) {
+		return tag
+	}
+
+	if name := taxonomy.NormalizeTag(normalized); strings.ToLower(name) == normalized {
+		return name
+	}
+
+	words := strings.Fields(normalized)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// splitChangelogBlocks separates the lines before the first release from the
+// release blocks that follow. A heading that is not a release header, such as
+// "## Fluent-Bit", starts a block of its own so releases never move across it.
+func splitChangelogBlocks(lines []string, dialect *helmlog.Dialect) ([]string, []changelogBlock) {
+	preamble := []string{}
+	blocks := []changelogBlock{}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if matches := dialect.ReleaseHeader.FindStringSubmatch(trimmed); matches != nil {
+			date, _ := helmlog.CanonicalReleaseDate(matches[2])
+			blocks = append(blocks, changelogBlock{
+				Release: &helmlog.Release{Version: matches[1], Date: date},
+				Lines:   []string{line},
+			})
+			continue
+		}
+
+		if len(blocks) > 0 && helmlog.IsGroupHeading(trimmed) {
+			blocks = append(blocks, changelogBlock{Lines: []string{line}})
+			continue
+		}
+
+		if len(blocks) == 0 {
+			preamble = append(preamble, line)
+			continue
+		}
+
+		last := &blocks[len(blocks)-1]
+		last.Lines = append(last.Lines, line)
+	}
+
+	return preamble, blocks
+}
+
+// sortChangelogBlocks orders each run of release blocks between group
+// headings by SemVer precedence, highest first, as the release-order rule
+// expects. Dates are ignored so a misdated release is reported by validate
+// rather than moved away from its neighbours.
+func sortChangelogBlocks(blocks []changelogBlock) {
+	start := 0
+	for start < len(blocks) {
+		if blocks[start].Release == nil {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(blocks) && blocks[end].Release != nil {
+			end++
+		}
+
+		run := blocks[start:end]
+		sort.SliceStable(run, func(i, j int) bool {
+			return helmlog.CompareVersions(run[i].Release.Version, run[j].Release.Version) > 0
+		})
+		start = end
+	}
+}
+
+func joinTrimmed(lines []string) string {
+	start := 0
+	for start < len(lines) && lines[start] == "" {
+		start++
+	}
+
+	end := len(lines)
+	for end > start && lines[end-1] == "" {
+		end--
+	}
+
+	return strings.Join(lines[start:end], "\n")
+}
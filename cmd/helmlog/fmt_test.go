@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+func TestFormatChangelogNormalizesLegacyFile(t *testing.T) {
+	input := `# Changelog
+
+## Fluentd
+
+
+### v1.16.5 / 2024-04-24
+* [UPGRADE] Upgrade Fluentd version to v1.16.5
+
+### v1.18.0 / 2025-1-5
+* [CHANGE] Update the coralogix API
+  * keep the nested bullet
+Some prose that must survive.
+
+## Fluent-Bit
+
+### v3.0.4 / 2024-05-21
+* [FIX] Upgrade Fluentbit version
+`
+
+	want := `# Changelog
+
+## Fluentd
+
+### v1.18.0 / 2025-01-05
+- [Change] Update the coralogix API
+  - keep the nested bullet
+Some prose that must survive.
+
+### v1.16.5 / 2024-04-24
+- [UPGRADE] Upgrade Fluentd version to v1.16.5
+
+## Fluent-Bit
+
+### v3.0.4 / 2024-05-21
+- [Fix] Upgrade Fluentbit version
+`
+
+	got, err := formatChangelog([]byte(input), parseOptions{Dialect: helmlog.DialectAuto})
+	if err != nil {
+		t.Fatalf("formatChangelog() error = %v", err)
+	}
+
+	if string(got) != want {
+		t.Fatalf("formatChangelog() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatChangelogHelmDialect(t *testing.T) {
+	input := "# Changelog\r\n\r\n## v0.0.2 / 2026-01-02\r\n- [:warning: CHANGE] [FEATURE] Bump collector\r\n\r\n### v0.0.3 / 2026-02-01\r\n- [CHORE] Bump chart dependency to opentelemetry-collector 0.1.0\r\n\r\n#### Changes from opentelemetry-collector 0.1.0:\r\n- [bug] Fix it\r\n"
+
+	want := `# Changelog
+
+### v0.0.3 / 2026-02-01
+- [Chore] Bump chart dependency to opentelemetry-collector 0.1.0
+
+#### Changes from opentelemetry-collector 0.1.0:
+- [Bug] Fix it
+
+### v0.0.2 / 2026-01-02
+- [:warning: Change] [Feature] Bump collector
+`
+
+	got, err := formatChangelog([]byte(input), parseOptions{Dialect: helmlog.DialectAuto})
+	if err != nil {
+		t.Fatalf("formatChangelog() error = %v", err)
+	}
+
+	if string(got) != want {
+		t.Fatalf("formatChangelog() =\n%s\nwant\n%s", got, want)
+	}
+
+	again, err := formatChangelog(got, parseOptions{Dialect: helmlog.DialectAuto})
+	if err != nil {
+		t.Fatalf("formatChangelog(formatted) error = %v", err)
+	}
+	if string(again) != string(got) {
+		t.Fatalf("formatChangelog() is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormatChangelogKeepsTagAliases(t *testing.T) {
+	config := mustBuildConfig(mergeConfigSettings(defaultConfigSettings, configSettings{
+		TaxonomyConfig: helmlog.TaxonomyConfig{Tags: map[string][]string{"Update": {"upgrade", "downgrade"}}},
+	}))
+
+	input := "### v0.0.2 / 2026-01-02\n- [UPGRADE] Upgrade Fluentd\n- [DOWNGRADE] Restore the image\n- [UPDATE] Bump the chart\n"
+	want := "### v0.0.2 / 2026-01-02\n- [Upgrade] Upgrade Fluentd\n- [Downgrade] Restore the image\n- [Update] Bump the chart\n"
+
+	got, err := formatChangelog([]byte(input), parseOptions{Dialect: helmlog.DialectHelm, Config: config})
+	if err != nil {
+		t.Fatalf("formatChangelog() error = %v", err)
+	}
+
+	if string(got) != want {
+		t.Fatalf("formatChangelog() = %q, want %q", string(got), want)
+	}
+}
+
+func TestFormatChangelogKeepsFencedCode(t *testing.T) {
+	input := "### v0.0.1 / 2026-01-01\n- [Feat] Example\n\n```yaml\n* [CHORE] not a bullet\n\n\nkey: value\n```\n"
+
+	got, err := formatChangelog([]byte(input), parseOptions{Dialect: helmlog.DialectHelm})
+	if err != nil {
+		t.Fatalf("formatChangelog() error = %v", err)
+	}
+
+	if string(got) != input {
+		t.Fatalf("formatChangelog() = %q, want unchanged %q", string(got), input)
+	}
+}
+
+func TestRunFmtCheckReportsUnformattedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	if err := os.WriteFile(path, []byte("### v0.0.1 / 2026-01-01\n- [FIX] Something\n"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	err := runFmt([]string{path}, fmtOptions{Check: true})
+	if err == nil || !strings.Contains(err.Error(), "1 changelog file(s) need formatting") {
+		t.Fatalf("runFmt(--check) error = %v, want formatting error", err)
+	}
+
+	if err := runFmt([]string{path}, fmtOptions{Write: true}); err != nil {
+		t.Fatalf("runFmt(--write) error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if got, want := string(content), "### v0.0.1 / 2026-01-01\n- [Fix] Something\n"; got != want {
+		t.Fatalf("written file = %q, want %q", got, want)
+	}
+
+	if err := runFmt([]string{path}, fmtOptions{Check: true}); err != nil {
+		t.Fatalf("runFmt(--check) after write error = %v", err)
+	}
+}
+
+func TestFormatChangelogOrdersByVersionOnly(t *testing.T) {
+	input := `### v0.0.3 / 2025-01-10
+- [Fix] Third
+
+### v0.0.2 / 2024-01-05
+- [Fix] Second, misdated
+
+### v0.0.1 / 2025-01-01
+- [Feat] First
+`
+
+	got, err := formatChangelog([]byte(input), parseOptions{Dialect: helmlog.DialectHelm})
+	if err != nil {
+		t.Fatalf("formatChangelog() error = %v", err)
+	}
+
+	if string(got) != input {
+		t.Fatalf("formatChangelog() moved a misdated release:\n%s", got)
+	}
+}
+
+// TestFormatChangelogKeepsRepositoryChangelogsValid formats every changelog
+// in the repository and checks that the ones that validate still do.
+func TestFormatChangelogKeepsRepositoryChangelogsValid(t *testing.T) {
+	paths := []string{}
+	err := filepath.WalkDir(filepath.Join("..", ".."), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && (entry.Name() == ".git" || entry.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() && entry.Name() == "CHANGELOG.md" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("filepath.WalkDir() error = %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no CHANGELOG.md found in the repository")
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			opts, err := parseOptions{Dialect: helmlog.DialectAuto}.withConfig(path)
+			if err != nil {
+				t.Fatalf("withConfig() error = %v", err)
+			}
+
+			original, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("os.ReadFile() error = %v", err)
+			}
+			before, diagnostics, err := parseMarkdownDiagnostics(bytes.NewReader(original), opts)
+			if err != nil {
+				t.Fatalf("parseMarkdownDiagnostics() error = %v", err)
+			}
+
+			formatted, err := formatChangelog(original, opts)
+			if err != nil {
+				t.Fatalf("formatChangelog() error = %v", err)
+			}
+			after, formattedDiagnostics, err := parseMarkdownDiagnostics(bytes.NewReader(formatted), opts)
+			if err != nil {
+				t.Fatalf("parseMarkdownDiagnostics(formatted) error = %v", err)
+			}
+
+			// Repaired headers and bullets can only add releases and entries
+			if after.ReleaseCount < before.ReleaseCount || after.EntryCount < before.EntryCount {
+				t.Fatalf("formatted file has %d releases and %d entries, want at least %d and %d", after.ReleaseCount, after.EntryCount, before.ReleaseCount, before.EntryCount)
+			}
+			if len(diagnostics) == 0 && len(formattedDiagnostics) > 0 {
+				t.Fatalf("formatted file no longer validates: %v", formattedDiagnostics[0])
+			}
+
+			again, err := formatChangelog(formatted, opts)
+			if err != nil {
+				t.Fatalf("formatChangelog(formatted) error = %v", err)
+			}
+			if !bytes.Equal(again, formatted) {
+				t.Fatal("formatChangelog() is not idempotent")
+			}
+		})
+	}
+}
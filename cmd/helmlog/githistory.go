@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+// chartCacheVersion is bumped whenever helmlog.Chart changes shape, so stale
+// cache entries are not decoded into the new model.
+const chartCacheVersion = "chart-v1"
+
+// chartSnapshot is the Chart.yaml of one commit in the history of a chart.
+type chartSnapshot struct {
+	Commit string
+	Time   time.Time
+	Blob   string
+	Chart  helmlog.Chart
+}
+
+// readChartHistory returns the Chart.yaml snapshots of every commit that
+// touched chartFile in historyRef, newest first. Git is run three times
+// whatever the length of the history: once for the commit list, once to
+// resolve each commit to its blob and once to stream the blobs that are not in
+// cacheDir. Snapshots that are missing or do not parse are skipped.
+func readChartHistory(repoRoot, historyRef, chartFile, cacheDir string) ([]chartSnapshot, error) {
+	commits, err := gitHistoryForPath(repoRoot, historyRef, chartFile)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		revisions = append(revisions, commit.Hash+":"+filepath.ToSlash(chartFile))
+	}
+	if len(revisions) == 0 {
+		return nil, nil
+	}
+
+	blobs, err := gitBlobHashes(repoRoot, revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := chartCache{dir: cacheDir}
+	charts := map[string]helmlog.Chart{}
+	missing := []string{}
+	for _, blob := range blobs {
+		if blob == "" {
+			continue
+		}
+		if _, ok := charts[blob]; ok {
+			continue
+		}
+		if chart, ok := cache.load(blob); ok {
+			charts[blob] = chart
+			continue
+		}
+		charts[blob] = helmlog.Chart{}
+		missing = append(missing, blob)
+	}
+
+	contents, err := gitReadBlobs(repoRoot, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range missing {
+		chart, err := helmlog.ParseChart(contents[blob])
+		if err != nil {
+			delete(charts, blob)
+			continue
+		}
+		charts[blob] = chart
+		if err := cache.store(blob, chart); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshots := make([]chartSnapshot, 0, len(commits))
+	for i, commit := range commits {
+		chart, ok := charts[blobs[i]]
+		if !ok {
+			continue
+		}
+		snapshots = append(snapshots, chartSnapshot{Commit: commit.Hash, Time: commit.Time, Blob: blobs[i], Chart: chart})
+	}
+
+	return snapshots, nil
+}
+
+// gitCommit is one commit listed by gitHistoryForPath.
+type gitCommit struct {
+	Hash string
+	Time time.Time
+}
+
+func gitHistoryForPath(repoRoot, historyRef, chartFile string) ([]gitCommit, error) {
+	historyRef = strings.TrimSpace(historyRef)
+	if historyRef == "" {
+		historyRef = "HEAD"
+	}
+
+	output, err := runGitCommand(repoRoot, "log", historyRef, "--format=%H %ct", "--", chartFile)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to read git history for %s at ref %q: %w", chartFile, historyRef, err)
+	}
+
+	commits := []gitCommit{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: unexpected git log line %q: %w", line, err)
+		}
+		commits = append(commits, gitCommit{Hash: fields[0], Time: time.Unix(seconds, 0).UTC()})
+	}
+
+	return commits, nil
+}
+
+// gitBlobHashes resolves each revision to a blob hash with a single
+// "git cat-file --batch-check" run. Revisions that do not name a blob, such
+// as a path deleted in that commit, resolve to "".
+func gitBlobHashes(repoRoot string, revisions []string) ([]string, error) {
+	blobs := make([]string, 0, len(revisions))
+	err := runGitCatFile(repoRoot, "--batch-check=%(objectname) %(objecttype)", revisions, func(r *bufio.Reader) error {
+		for range revisions {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return err
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[1] == "blob" {
+				blobs = append(blobs, fields[0])
+			} else {
+				blobs = append(blobs, "")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blobs, nil
+}
+
+// gitReadBlobs streams the content of blobs with a single
+// "git cat-file --batch" run.
+func gitReadBlobs(repoRoot string, blobs []string) (map[string][]byte, error) {
+	contents := make(map[string][]byte, len(blobs))
+	if len(blobs) == 0 {
+		return contents, nil
+	}
+
+	err := runGitCatFile(repoRoot, "--batch", blobs, func(r *bufio.Reader) error {
+		for _, blob := range blobs {
+			header, err := r.ReadString('\n')
+			if err != nil {
+				return err
+			}
+
+			fields := strings.Fields(header)
+			if len(fields) != 3 {
+				return fmt.Errorf("unexpected object header %q for %s", strings.TrimSpace(header), blob)
+			}
+			size, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return fmt.Errorf("unexpected object header %q for %s", strings.TrimSpace(header), blob)
+			}
+
+			content := make([]byte, size+1)
+			if _, err := io.ReadFull(r, content); err != nil {
+				return err
+			}
+			contents[blob] = content[:size]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+// runGitCatFile feeds objects to "git cat-file <mode>" on stdin and hands its
+// output to read.
+func runGitCatFile(repoRoot, mode string, objects []string, read func(*bufio.Reader) error) error {
+	cmd := exec.Command("git", "cat-file", mode)
+	cmd.Dir = repoRoot
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to start git cat-file: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to start git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ERROR: failed to start git cat-file: %w", err)
+	}
+
+	// Writing from a separate goroutine keeps git from blocking on a full
+	// stdout pipe while objects are still being written.
+	go func() {
+		defer stdin.Close()
+		_, _ = io.WriteString(stdin, strings.Join(objects, "\n")+"\n")
+	}()
+
+	readErr := read(bufio.NewReader(stdout))
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	if readErr != nil {
+		return fmt.Errorf("ERROR: failed to read git cat-file output: %w", readErr)
+	}
+	if waitErr != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("ERROR: git cat-file failed: %w: %s", waitErr, message)
+		}
+		return fmt.Errorf("ERROR: git cat-file failed: %w", waitErr)
+	}
+
+	return nil
+}
+
+// chartCache keeps parsed Chart.yaml blobs on disk, one JSON file per blob
+// hash. Blobs are immutable, so entries never need to be invalidated. A zero
+// chartCache is disabled.
+type chartCache struct {
+	dir string
+}
+
+func (c chartCache) path(blob string) string {
+	return filepath.Join(c.dir, chartCacheVersion, blob[:2], blob[2:]+".json")
+}
+
+func (c chartCache) load(blob string) (helmlog.Chart, bool) {
+	if c.dir == "" || len(blob) < 3 {
+		return helmlog.Chart{}, false
+	}
+
+	content, err := os.ReadFile(c.path(blob))
+	if err != nil {
+		return helmlog.Chart{}, false
+	}
+
+	var chart helmlog.Chart
+	if err := json.Unmarshal(content, &chart); err != nil {
+		return helmlog.Chart{}, false
+	}
+
+	return chart, true
+}
+
+func (c chartCache) store(blob string, chart helmlog.Chart) error {
+	if c.dir == "" || len(blob) < 3 {
+		return nil
+	}
+
+	content, err := json.Marshal(chart)
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to encode cache entry for %s: %w", blob, err)
+	}
+
+	path := c.path(blob)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ERROR: failed to create cache directory: %w", err)
+	}
+
+	// Write through a temporary file so a concurrent run never reads a
+	// partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to write cache entry for %s: %w", blob, err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ERROR: failed to write cache entry for %s: %w", blob, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ERROR: failed to write cache entry for %s: %w", blob, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ERROR: failed to write cache entry for %s: %w", blob, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadChartHistorySkipsDeletedSnapshots(t *testing.T) {
+	chartPath := filepath.Join("otel-linux-standalone", "Chart.yaml")
+	repoRoot := setupChartMappingTestRepo(t, chartPath)
+
+	runTestGit(t, repoRoot, "rm", "-q", chartPath)
+	runTestGit(t, repoRoot, "commit", "-m", "main remove chart")
+
+	snapshots, err := readChartHistory(repoRoot, "HEAD", chartPath, "")
+	if err != nil {
+		t.Fatalf("readChartHistory() error = %v", err)
+	}
+
+	versions := []string{}
+	for _, snapshot := range snapshots {
+		if len(snapshot.Commit) != 40 || len(snapshot.Blob) != 40 {
+			t.Fatalf("snapshot = %#v, want full commit and blob hashes", snapshot)
+		}
+		versions = append(versions, snapshot.Chart.Version)
+	}
+	if want := []string{"0.0.2", "0.0.1"}; !reflect.DeepEqual(versions, want) {
+		t.Fatalf("versions = %#v, want %#v", versions, want)
+	}
+}
+
+func TestReadChartHistoryUsesBlobCache(t *testing.T) {
+	chartPath := filepath.Join("otel-linux-standalone", "Chart.yaml")
+	repoRoot := setupChartMappingTestRepo(t, chartPath)
+	cacheDir := t.TempDir()
+
+	snapshots, err := readChartHistory(repoRoot, "HEAD", chartPath, cacheDir)
+	if err != nil {
+		t.Fatalf("readChartHistory() error = %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("len(snapshots) = %d, want 2", len(snapshots))
+	}
+
+	cache := chartCache{dir: cacheDir}
+	for _, snapshot := range snapshots {
+		cached, ok := cache.load(snapshot.Blob)
+		if !ok {
+			t.Fatalf("cache.load(%s) missing after first run", snapshot.Blob)
+		}
+		if !reflect.DeepEqual(cached, snapshot.Chart) {
+			t.Fatalf("cached chart = %#v, want %#v", cached, snapshot.Chart)
+		}
+	}
+
+	// A second run must read the cache instead of git, which a tampered
+	// entry makes visible.
+	tampered := snapshots[0].Chart
+	tampered.Version = "9.9.9-cached"
+	if err := cache.store(snapshots[0].Blob, tampered); err != nil {
+		t.Fatalf("cache.store() error = %v", err)
+	}
+
+	snapshots, err = readChartHistory(repoRoot, "HEAD", chartPath, cacheDir)
+	if err != nil {
+		t.Fatalf("readChartHistory() error = %v", err)
+	}
+	if got := snapshots[0].Chart.Version; got != "9.9.9-cached" {
+		t.Fatalf("Version = %q, want cached entry", got)
+	}
+
+	// Unreadable entries fall back to git.
+	if err := os.WriteFile(cache.path(snapshots[0].Blob), []byte("{"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	snapshots, err = readChartHistory(repoRoot, "HEAD", chartPath, cacheDir)
+	if err != nil {
+		t.Fatalf("readChartHistory() error = %v", err)
+	}
+	if got := snapshots[0].Chart.Version; got != "0.0.2" {
+		t.Fatalf("Version = %q, want %q", got, "0.0.2")
+	}
+}
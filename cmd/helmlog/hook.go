@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+const (
+	changelogFileName = "CHANGELOG.md"
+	// hookMarker identifies hooks written by helmlog, which install may
+	// replace without --force.
+	hookMarker = "# Installed by helmlog hook install."
+)
+
+type hookInstallOptions struct {
+	Command string
+	Force   bool
+}
+
+func newHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Validate staged changelogs from a git pre-commit hook",
+	}
+
+	cmd.AddCommand(newHookInstallCmd())
+	cmd.AddCommand(newHookRunCmd())
+
+	return cmd
+}
+
+func newHookInstallCmd() *cobra.Command {
+	opts := hookInstallOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Write a pre-commit hook that runs helmlog hook run",
+		Long: `Write a git pre-commit hook that runs "helmlog hook run" from the repository
+root. The hook honours core.hooksPath. An existing hook that was not written by
+helmlog is only replaced with --force.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runHookInstall(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Command, "command", "go run ./cmd/helmlog", "Command the hook uses to run helmlog, relative to the repository root")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Replace an existing pre-commit hook")
+
+	return cmd
+}
+
+func newHookRunCmd() *cobra.Command {
+	opts := parseOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Validate the staged content of every staged CHANGELOG.md",
+		Long: `Validate the staged content of every staged CHANGELOG.md, as the pre-commit hook
+does. The index is read rather than the working tree, so unstaged fixes do not
+hide problems in what is about to be committed.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runHookRun(opts)
+		},
+	}
+
+	addParseFlags(cmd, &opts)
+
+	return cmd
+}
+
+func runHookInstall(opts hookInstallOptions) error {
+	hooksDir, err := runGitCommand("", "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to locate git hooks directory: %w", err)
+	}
+
+	hookPath := filepath.Join(strings.TrimSpace(hooksDir), "pre-commit")
+	existing, err := os.ReadFile(hookPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ERROR: failed to read %s: %w", hookPath, err)
+	}
+	if err == nil && !strings.Contains(string(existing), hookMarker) && !opts.Force {
+		return fmt.Errorf("ERROR: %s already exists; use --force to replace it", hookPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return fmt.Errorf("ERROR: failed to create %s: %w", filepath.Dir(hookPath), err)
+	}
+	if err := os.WriteFile(hookPath, []byte(renderPreCommitHook(opts.Command)), 0o755); err != nil {
+		return fmt.Errorf("ERROR: failed to write %s: %w", hookPath, err)
+	}
+	// WriteFile keeps the mode of a replaced hook.
+	if err := os.Chmod(hookPath, 0o755); err != nil {
+		return fmt.Errorf("ERROR: failed to make %s executable: %w", hookPath, err)
+	}
+
+	fmt.Printf("Installed pre-commit hook at %s\n", hookPath)
+	return nil
+}
+
+func renderPreCommitHook(command string) string {
+	return `#!/bin/sh
+` + hookMarker + `
+# Validates the staged CHANGELOG.md files before each commit.
+cd "$(git rev-parse --show-toplevel)" || exit 1
+exec ` + command + ` hook run
+`
+}
+
+// runHookRun validates the index version of the staged changelogs. The
+// configuration is still read from the working tree.
+func runHookRun(opts parseOptions) error {
+	repoRoot, err := gitRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	paths, err := stagedChangelogs(repoRoot)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	diagnostics := []helmlog.Diagnostic{}
+	for _, path := range paths {
+		content, err := runGitCommand(repoRoot, "cat-file", "blob", ":"+path)
+		if err != nil {
+			return fmt.Errorf("ERROR: failed to read staged %s: %w", path, err)
+		}
+
+		fileOpts, err := opts.withConfig(filepath.Join(repoRoot, filepath.FromSlash(path)))
+		if err != nil {
+			return err
+		}
+
+		_, fileDiagnostics, err := parseMarkdownDiagnostics(strings.NewReader(content), fileOpts)
+		if err != nil {
+			return err
+		}
+		for i := range fileDiagnostics {
+			fileDiagnostics[i].Path = path
+		}
+		diagnostics = append(diagnostics, fileDiagnostics...)
+	}
+
+	if len(diagnostics) > 0 {
+		return diagnosticsError(diagnostics, len(paths))
+	}
+
+	fmt.Printf("OK: %d staged changelog file(s) validated\n", len(paths))
+	return nil
+}
+
+// stagedChangelogs lists the CHANGELOG.md files added, copied, modified or
+// renamed in the index, relative to the repository root.
+func stagedChangelogs(repoRoot string) ([]string, error) {
+	output, err := runGitCommand(repoRoot, "diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to list staged files: %w", err)
+	}
+
+	paths := []string{}
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" && filepath.Base(path) == changelogFileName {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunHookInstall(t *testing.T) {
+	repoRoot := t.TempDir()
+	runTestGit(t, repoRoot, "init", "-b", "main")
+	t.Chdir(repoRoot)
+
+	hookPath := filepath.Join(repoRoot, ".git", "hooks", "pre-commit")
+	writeTestFile(t, hookPath, "#!/bin/sh\nmake lint\n")
+
+	err := runHookInstall(hookInstallOptions{Command: "helmlog"})
+	if err == nil || !strings.Contains(err.Error(), "use --force to replace it") {
+		t.Fatalf("runHookInstall() error = %v, want existing hook error", err)
+	}
+
+	if err := runHookInstall(hookInstallOptions{Command: "helmlog", Force: true}); err != nil {
+		t.Fatalf("runHookInstall(--force) error = %v", err)
+	}
+	// A hook written by helmlog is replaced without --force.
+	if err := runHookInstall(hookInstallOptions{Command: "go run ./cmd/helmlog"}); err != nil {
+		t.Fatalf("runHookInstall() over own hook error = %v", err)
+	}
+
+	content, err := os.ReadFile(hookPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(content), hookMarker) || !strings.HasSuffix(string(content), "exec go run ./cmd/helmlog hook run\n") {
+		t.Fatalf("pre-commit hook = %q", content)
+	}
+
+	info, err := os.Stat(hookPath)
+	if err != nil {
+		t.Fatalf("os.Stat() error = %v", err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("pre-commit hook mode = %v, want executable", info.Mode())
+	}
+}
+
+func TestRunHookRunReadsStagedContent(t *testing.T) {
+	repoRoot := t.TempDir()
+	runTestGit(t, repoRoot, "init", "-b", "main")
+	t.Chdir(repoRoot)
+
+	path := filepath.Join("otel-linux-standalone", changelogFileName)
+	valid := "# Changelog\n\n### v0.0.1 / 2026-01-01\n- [Feat] Initial release\n"
+	broken := strings.Replace(valid, "[Feat]", "[Feature request]", 1)
+
+	// Nothing staged.
+	if err := runHookRun(parseOptions{}); err != nil {
+		t.Fatalf("runHookRun() without staged changelogs error = %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(repoRoot, path), broken)
+	runTestGit(t, repoRoot, "add", path)
+	// The fix is only in the working tree.
+	writeTestFile(t, filepath.Join(repoRoot, path), valid)
+
+	err := runHookRun(parseOptions{})
+	if err == nil || !strings.Contains(err.Error(), "Location: otel-linux-standalone/CHANGELOG.md:4:3") {
+		t.Fatalf("runHookRun() error = %v, want problem in staged content", err)
+	}
+
+	runTestGit(t, repoRoot, "add", path)
+	if err := runHookRun(parseOptions{}); err != nil {
+		t.Fatalf("runHookRun() after staging the fix error = %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	inventoryFormatJSON = "json"
+	inventoryFormatYAML = "yaml"
+)
+
+// inventorySkippedDirs are never searched for charts, changelogs or VERSION
+// files.
+var inventorySkippedDirs = map[string]bool{
+	"node_modules": true,
+	"testdata":     true,
+	"vendor":       true,
+}
+
+// inventoryManifest lists every chart, VERSION file and changelog of the
+// repository. Paths are relative to the repository root and use forward
+// slashes.
+type inventoryManifest struct {
+	Charts          []inventoryChart       `json:"charts" yaml:"charts"`
+	VersionFiles    []inventoryVersionFile `json:"version_files" yaml:"version_files"`
+	Changelogs      []inventoryChangelog   `json:"changelogs" yaml:"changelogs"`
+	Inconsistencies []inventoryProblem     `json:"inconsistencies" yaml:"inconsistencies"`
+}
+
+type inventoryChart struct {
+	Path          string                    `json:"path" yaml:"path"`
+	Name          string                    `json:"name" yaml:"name"`
+	Version       string                    `json:"version" yaml:"version"`
+	AppVersion    string                    `json:"app_version,omitempty" yaml:"app_version,omitempty"`
+	Dependencies  []helmlog.ChartDependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Changelog     string                    `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+	LatestRelease *inventoryRelease         `json:"latest_release,omitempty" yaml:"latest_release,omitempty"`
+}
+
+type inventoryVersionFile struct {
+	Path          string            `json:"path" yaml:"path"`
+	Version       string            `json:"version" yaml:"version"`
+	Changelog     string            `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+	LatestRelease *inventoryRelease `json:"latest_release,omitempty" yaml:"latest_release,omitempty"`
+}
+
+type inventoryChangelog struct {
+	Path          string            `json:"path" yaml:"path"`
+	Dialect       string            `json:"dialect,omitempty" yaml:"dialect,omitempty"`
+	Releases      int               `json:"releases" yaml:"releases"`
+	Entries       int               `json:"entries" yaml:"entries"`
+	LatestRelease *inventoryRelease `json:"latest_release,omitempty" yaml:"latest_release,omitempty"`
+	Problems      int               `json:"problems" yaml:"problems"`
+}
+
+type inventoryRelease struct {
+	Version string `json:"version" yaml:"version"`
+	Date    string `json:"date" yaml:"date"`
+}
+
+type inventoryProblem struct {
+	Path   string `json:"path" yaml:"path"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// inventoryChangelogInfo is a parsed changelog shared by every chart and
+// VERSION file that points at it. Changelogs such as logs/CHANGELOG.md keep
+// several components under their own "## " headings, so the newest release is
+// tracked per heading: groups maps a version to its heading and groupLatest
+// holds the newest release under each one.
+type inventoryChangelogInfo struct {
+	entry       inventoryChangelog
+	groups      map[string]int
+	groupLatest map[int]*inventoryRelease
+	content     string
+	dialect     string
+}
+
+// latestFor returns the newest release of the group version belongs to, or
+// the newest release of the changelog for an unknown version.
+func (i *inventoryChangelogInfo) latestFor(version string) *inventoryRelease {
+	if group, ok := i.groups[trimVersionPrefix(version)]; ok {
+		return i.groupLatest[group]
+	}
+
+	return i.entry.LatestRelease
+}
+
+type inventoryOptions struct {
+	OutputPath string
+	Format     string
+}
+
+func newInventoryCmd() *cobra.Command {
+	opts := inventoryOptions{Format: inventoryFormatJSON}
+
+	cmd := &cobra.Command{
+		Use:   "inventory [dir]",
+		Short: "Write a manifest of every chart, changelog and VERSION file",
+		Long: `Write a manifest of every Chart.yaml, CHANGELOG.md and VERSION file under
+dir, which defaults to the repository root.
+
+Each chart and VERSION file is paired with the nearest CHANGELOG.md at or above
+it. The manifest lists their versions, chart dependencies and the newest
+changelog release, and reports inconsistencies: versions missing from or
+behind their changelog, charts without a changelog, changelogs that do not
+validate and opentelemetry-collector versions without a bump entry.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			root := ""
+			if len(args) > 0 {
+				root = args[0]
+			}
+			return runInventory(root, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().StringVar(&opts.Format, "format", inventoryFormatJSON, "Output format (json, yaml)")
+
+	return cmd
+}
+
+func runInventory(root string, opts inventoryOptions) error {
+	if opts.Format != inventoryFormatJSON && opts.Format != inventoryFormatYAML {
+		return fmt.Errorf("ERROR: unknown inventory format %q (want json or yaml)", opts.Format)
+	}
+
+	if root == "" {
+		repoRoot, err := gitRepoRoot()
+		if err != nil {
+			return err
+		}
+		root = repoRoot
+	}
+
+	manifest, err := buildInventory(root)
+	if err != nil {
+		return err
+	}
+
+	output, err := marshalInventory(manifest, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	if opts.OutputPath == "" || opts.OutputPath == "-" {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("ERROR: failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(opts.OutputPath, output, 0o644); err != nil {
+		return fmt.Errorf("ERROR: failed to write %s: %w", opts.OutputPath, err)
+	}
+
+	fmt.Printf("Wrote %s with %d charts, %d VERSION files, %d changelogs and %d inconsistencies\n", opts.OutputPath, len(manifest.Charts), len(manifest.VersionFiles), len(manifest.Changelogs), len(manifest.Inconsistencies))
+
+	return nil
+}
+
+func buildInventory(root string) (inventoryManifest, error) {
+	manifest := inventoryManifest{
+		Charts:          []inventoryChart{},
+		VersionFiles:    []inventoryVersionFile{},
+		Changelogs:      []inventoryChangelog{},
+		Inconsistencies: []inventoryProblem{},
+	}
+
+	charts, versionFiles, changelogs := []string{}, []string{}, []string{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || inventorySkippedDirs[entry.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch entry.Name() {
+		case "Chart.yaml":
+			charts = append(charts, path)
+		case "VERSION":
+			versionFiles = append(versionFiles, path)
+		case changelogFileName:
+			changelogs = append(changelogs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return inventoryManifest{}, fmt.Errorf("ERROR: failed to scan %s: %w", root, err)
+	}
+
+	relative := func(path string) string {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return filepath.ToSlash(path)
+		}
+		return filepath.ToSlash(rel)
+	}
+	report := func(path, reason string) {
+		manifest.Inconsistencies = append(manifest.Inconsistencies, inventoryProblem{Path: relative(path), Reason: reason})
+	}
+
+	parsed := map[string]*inventoryChangelogInfo{}
+	for _, path := range changelogs {
+		info, err := inventoryParseChangelog(path)
+		if err != nil {
+			return inventoryManifest{}, err
+		}
+		info.entry.Path = relative(path)
+		parsed[path] = info
+
+		manifest.Changelogs = append(manifest.Changelogs, info.entry)
+		if info.entry.Problems > 0 {
+			report(path, fmt.Sprintf("changelog has %d validation problems; run helmlog validate %s", info.entry.Problems, relative(path)))
+		}
+	}
+
+	changelogFor := func(path string) (string, *inventoryChangelogInfo) {
+		found, err := findChangelogFile(filepath.Dir(path))
+		if err != nil {
+			return "", nil
+		}
+		found = filepath.Clean(found)
+		return found, parsed[found]
+	}
+
+	checkVersion := func(path, version string, changelogPath string, info *inventoryChangelogInfo) {
+		if info == nil {
+			report(path, "no CHANGELOG.md found at or above this file")
+			return
+		}
+		latest := info.latestFor(version)
+		if latest == nil {
+			return
+		}
+
+		if _, ok := info.groups[trimVersionPrefix(version)]; !ok {
+			report(path, fmt.Sprintf("version %s is not a release in %s", version, relative(changelogPath)))
+		} else if trimVersionPrefix(latest.Version) != trimVersionPrefix(version) {
+			report(path, fmt.Sprintf("version %s is behind the newest release %s in %s", version, latest.Version, relative(changelogPath)))
+		}
+	}
+
+	for _, path := range charts {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return inventoryManifest{}, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+		}
+
+		chart, err := helmlog.ParseChart(content)
+		if err != nil {
+			report(path, err.Error())
+			continue
+		}
+
+		entry := inventoryChart{
+			Path:         relative(path),
+			Name:         chart.Name,
+			Version:      chart.Version,
+			AppVersion:   chart.AppVersion,
+			Dependencies: chart.Dependencies,
+		}
+		changelogPath, info := changelogFor(path)
+		if info != nil {
+			entry.Changelog = relative(changelogPath)
+			entry.LatestRelease = info.latestFor(chart.Version)
+		}
+		manifest.Charts = append(manifest.Charts, entry)
+
+		if chart.Version == "" {
+			report(path, "chart has no version")
+			continue
+		}
+		checkVersion(path, chart.Version, changelogPath, info)
+
+		if dependency, ok := chart.Dependency(collectorDependencyName); ok && info != nil {
+			if !hasDependencyBumpEntry(info.content, info.dialect, dependency, "") {
+				report(path, fmt.Sprintf("%s dependency is at %s but %s has no %q entry", collectorDependencyName, dependency.Version, relative(changelogPath), dependencyBumpPhrase(dependency)))
+			}
+		}
+	}
+
+	for _, path := range versionFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return inventoryManifest{}, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+		}
+
+		entry := inventoryVersionFile{Path: relative(path), Version: strings.TrimSpace(string(content))}
+		changelogPath, info := changelogFor(path)
+		if info != nil {
+			entry.Changelog = relative(changelogPath)
+			entry.LatestRelease = info.latestFor(entry.Version)
+		}
+		manifest.VersionFiles = append(manifest.VersionFiles, entry)
+
+		if entry.Version == "" {
+			report(path, "VERSION file is empty")
+			continue
+		}
+		checkVersion(path, entry.Version, changelogPath, info)
+	}
+
+	return manifest, nil
+}
+
+// inventoryParseChangelog parses a changelog for the manifest. Validation
+// problems are counted rather than returned, so one broken changelog does not
+// hide the rest of the inventory.
+func inventoryParseChangelog(path string) (*inventoryChangelogInfo, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+	}
+
+	result, diagnostics, err := parseFileDiagnostics(path, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	info := &inventoryChangelogInfo{
+		entry: inventoryChangelog{
+			Dialect:  result.Dialect,
+			Releases: result.ReleaseCount,
+			Entries:  result.EntryCount,
+			Problems: len(diagnostics),
+		},
+		groups:      map[string]int{},
+		groupLatest: map[int]*inventoryRelease{},
+		content:     string(content),
+		dialect:     result.Dialect,
+	}
+
+	for _, release := range result.Log.Releases {
+		info.entry.LatestRelease = newerInventoryRelease(info.entry.LatestRelease, release.Version, release.Date)
+	}
+
+	// Release headers are matched before group headings, as the parser
+	// does, because some dialects use "## " for both.
+	lines := strings.Split(info.content, "\n")
+	dialect, err := resolveDialect(result.Dialect, lines)
+	if err != nil {
+		return info, nil
+	}
+	group := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if matches := dialect.ReleaseHeader.FindStringSubmatch(trimmed); matches != nil {
+			version := trimVersionPrefix(matches[1])
+			if _, ok := info.groups[version]; !ok {
+				info.groups[version] = group
+			}
+			date, err := helmlog.CanonicalReleaseDate(matches[2])
+			if err != nil {
+				date = matches[2]
+			}
+			info.groupLatest[group] = newerInventoryRelease(info.groupLatest[group], matches[1], date)
+			continue
+		}
+		if helmlog.IsGroupHeading(trimmed) {
+			group++
+		}
+	}
+
+	return info, nil
+}
+
+// newerInventoryRelease returns the newer of latest and the given release,
+// in the date and version order of helmlog.Sort. latest may be nil.
+func newerInventoryRelease(latest *inventoryRelease, version, date string) *inventoryRelease {
+	candidate := helmlog.Release{Version: version, Date: date}
+	if latest == nil || candidate.NewerThan(helmlog.Release{Version: latest.Version, Date: latest.Date}) {
+		return &inventoryRelease{Version: version, Date: date}
+	}
+
+	return latest
+}
+
+func marshalInventory(manifest inventoryManifest, format string) ([]byte, error) {
+	if format == inventoryFormatYAML {
+		var buffer bytes.Buffer
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(manifest); err != nil {
+			return nil, fmt.Errorf("ERROR: failed to encode yaml: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("ERROR: failed to encode yaml: %w", err)
+		}
+		return buffer.Bytes(), nil
+	}
+
+	output, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to encode json: %w", err)
+	}
+
+	return append(output, '\n'), nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildInventory(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("os.Mkdir() error = %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(root, "otel-linux-standalone", "Chart.yaml"), `apiVersion: v2
+name: otel-linux-standalone
+version: 0.0.2
+dependencies:
+  - name: opentelemetry-collector
+    alias: opentelemetry-agent
+    version: "0.101.0"
+`)
+	writeTestFile(t, filepath.Join(root, "otel-linux-standalone", "CHANGELOG.md"), `## OpenTelemetry Linux Standalone
+
+### v0.0.2 / 2026-01-02
+- [Feat] Bump chart dependency to opentelemetry-collector 0.101.0
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`)
+
+	writeTestFile(t, filepath.Join(root, "otel-integration", "k8s-helm", "Chart.yaml"), "apiVersion: v2\nname: otel-integration\nversion: 0.0.1\n")
+	writeTestFile(t, filepath.Join(root, "otel-integration", "CHANGELOG.md"), `## OpenTelemetry-Integration
+
+### v0.0.2 / 2026-01-02
+- [Fix] Second release
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`)
+
+	writeTestFile(t, filepath.Join(root, "logs", "fluentd", "image", "VERSION"), "v1.18.0-4\n")
+	writeTestFile(t, filepath.Join(root, "logs", "fluent-bit", "image", "VERSION"), "v3.2.10\n")
+	writeTestFile(t, filepath.Join(root, "logs", "CHANGELOG.md"), `# Changelog
+
+## Fluentd
+
+### v1.18.0-4 / 2025-04-10
+- [Update] Bump fluentd
+
+### v1.18.0 / 2025-01-05
+- [Update] Bump fluentd
+
+## Fluent-Bit
+
+### v3.2.10-1 / 2025-05-06
+- [Fix] Rebuild image
+
+### v3.2.10 / 2025-04-15
+- [Update] Bump fluent-bit
+`)
+
+	writeTestFile(t, filepath.Join(root, configFileName), "overrides:\n  - paths: [logs]\n    rules:\n      release-order: false\n")
+
+	writeTestFile(t, filepath.Join(root, "metrics", "operator", "Chart.yaml"), "apiVersion: v2\nname: operator\nversion: 0.0.4\n")
+	writeTestFile(t, filepath.Join(root, "otel-installer", "CHANGELOG.md"), "## Installer\n\n### v0.1.0 / 2026-01-01\n- [Unknown] Something\n")
+	writeTestFile(t, filepath.Join(root, ".github", "Chart.yaml"), "apiVersion: v2\nname: hidden\nversion: 9.9.9\n")
+
+	manifest, err := buildInventory(root)
+	if err != nil {
+		t.Fatalf("buildInventory() error = %v", err)
+	}
+
+	charts := []string{}
+	for _, chart := range manifest.Charts {
+		charts = append(charts, chart.Path+"@"+chart.Version)
+	}
+	wantCharts := []string{"metrics/operator/Chart.yaml@0.0.4", "otel-integration/k8s-helm/Chart.yaml@0.0.1", "otel-linux-standalone/Chart.yaml@0.0.2"}
+	if !reflect.DeepEqual(charts, wantCharts) {
+		t.Fatalf("charts = %#v, want %#v", charts, wantCharts)
+	}
+
+	linux := manifest.Charts[2]
+	if linux.Changelog != "otel-linux-standalone/CHANGELOG.md" || linux.LatestRelease == nil || linux.LatestRelease.Version != "v0.0.2" || len(linux.Dependencies) != 1 {
+		t.Fatalf("linux chart = %#v", linux)
+	}
+
+	if len(manifest.VersionFiles) != 2 {
+		t.Fatalf("len(VersionFiles) = %d, want 2", len(manifest.VersionFiles))
+	}
+	if got := manifest.VersionFiles[1]; got.Path != "logs/fluentd/image/VERSION" || got.LatestRelease.Version != "v1.18.0-4" {
+		t.Fatalf("fluentd VERSION = %#v, want newest release of its own section", got)
+	}
+
+	if len(manifest.Changelogs) != 4 {
+		t.Fatalf("len(Changelogs) = %d, want 4", len(manifest.Changelogs))
+	}
+
+	problems := []string{}
+	for _, problem := range manifest.Inconsistencies {
+		problems = append(problems, problem.Path+": "+problem.Reason)
+	}
+	wantProblems := []string{
+		"otel-installer/CHANGELOG.md: changelog has 1 validation problems; run helmlog validate otel-installer/CHANGELOG.md",
+		"metrics/operator/Chart.yaml: no CHANGELOG.md found at or above this file",
+		"otel-integration/k8s-helm/Chart.yaml: version 0.0.1 is behind the newest release v0.0.2 in otel-integration/CHANGELOG.md",
+		"logs/fluent-bit/image/VERSION: version v3.2.10 is behind the newest release v3.2.10-1 in logs/CHANGELOG.md",
+	}
+	if !reflect.DeepEqual(problems, wantProblems) {
+		t.Fatalf("inconsistencies = %#v, want %#v", problems, wantProblems)
+	}
+
+	for _, format := range []string{inventoryFormatJSON, inventoryFormatYAML} {
+		output, err := marshalInventory(manifest, format)
+		if err != nil {
+			t.Fatalf("marshalInventory(%s) error = %v", format, err)
+		}
+
+		var decoded inventoryManifest
+		if format == inventoryFormatJSON {
+			err = json.Unmarshal(output, &decoded)
+		} else {
+			err = yaml.Unmarshal(output, &decoded)
+		}
+		if err != nil {
+			t.Fatalf("decode %s error = %v\n%s", format, err, output)
+		}
+		if !reflect.DeepEqual(decoded, manifest) {
+			t.Fatalf("decoded %s manifest = %#v, want %#v", format, decoded, manifest)
+		}
+	}
+}
+
+func TestInventoryParseChangelogOrdersGroupsLikeLatestRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	writeTestFile(t, path, `# Changelog
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+
+### v0.0.2 / 2026-01-02
+- [Fix] Listed below an older release
+`)
+
+	info, err := inventoryParseChangelog(path)
+	if err != nil {
+		t.Fatalf("inventoryParseChangelog() error = %v", err)
+	}
+
+	want := &inventoryRelease{Version: "v0.0.2", Date: "2026-01-02"}
+	if !reflect.DeepEqual(info.entry.LatestRelease, want) {
+		t.Fatalf("LatestRelease = %#v, want %#v", info.entry.LatestRelease, want)
+	}
+	if got := info.latestFor("0.0.1"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("latestFor(0.0.1) = %#v, want %#v", got, want)
+	}
+}
+
+func TestRunInventoryRejectsUnknownFormat(t *testing.T) {
+	err := runInventory(t.TempDir(), inventoryOptions{Format: "toml"})
+	if err == nil || !strings.Contains(err.Error(), "unknown inventory format") {
+		t.Fatalf("runInventory() error = %v, want unknown format error", err)
+	}
+}
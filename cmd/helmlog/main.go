@@ -1,18 +1,17 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
-	"sort"
-	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
@@ -20,57 +19,34 @@ import (
 )
 
 var (
-	releaseHeaderPattern = regexp.MustCompile(`^### (v\d+\.\d+\.\d+) / (\d{4}-\d{2}-\d{2})$`)
-	nowFunc              = time.Now
+	versionPattern = regexp.MustCompile(`^v?` + helmlog.SemverExpr + `$`)
+	nowFunc        = time.Now
 )
 
-var allowedTags = map[string]bool{
-	"feat":                      true,
-	"feature":                   true,
-	"fix":                       true,
-	"bug":                       true,
-	"change":                    true,
-	"breaking":                  true,
-	"chore":                     true,
-	"revert":                    true,
-	"update":                    true,
-	"docs":                      true,
-	":warning: change":          true,
-	":warning: breaking change": true,
-}
+// parseOptions selects the dialect and configuration used to read a
+// changelog. When Config is nil it is loaded from ConfigPath or discovered
+// from .helmlog.yaml files next to the changelog. Today and Timezone set the
+// day future release dates are checked against.
+type parseOptions struct {
+	Dialect       string
+	ConfigPath    string
+	DisabledRules []string
+	Config        *changelogConfig
+	Today         string
+	Timezone      string
 
-const droppedEntryPhrase = "Bump chart dependency to opentelemetry-collector"
+	// revision is the git revision the changelog was read from, empty for
+	// the working tree.
+	revision string
+}
 
 type parseResult struct {
+	Dialect      string
 	Log          helmlog.Changelog
 	ReleaseCount int
 	EntryCount   int
 }
 
-type parseError struct {
-	ReleaseVersion string
-	ReleaseDate    string
-	Reason         string
-	Line           string
-}
-
-type chartMappingResult struct {
-	Mappings []chartMappingEntry `json:"mappings"`
-}
-
-type chartMappingEntry struct {
-	ChartVersion          string `json:"chart_version"`
-	CollectorChartVersion string `json:"collector_chart_version"`
-}
-
-func (e parseError) Error() string {
-	if e.ReleaseVersion == "" {
-		return fmt.Sprintf("ERROR: invalid changelog entry\nReason: %s\nLine: %s", e.Reason, e.Line)
-	}
-
-	return fmt.Sprintf("ERROR: invalid entry at release %s (%s)\nReason: %s\nLine: %s", e.ReleaseVersion, e.ReleaseDate, e.Reason, e.Line)
-}
-
 func main() {
 	rootCmd := newRootCmd()
 	if err := rootCmd.Execute(); err != nil {
@@ -90,54 +66,105 @@ func newRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newValidateCmd())
 	rootCmd.AddCommand(newGenerateCmd())
 	rootCmd.AddCommand(newChartMappingCmd())
+	rootCmd.AddCommand(newRenderCmd())
+	rootCmd.AddCommand(newFmtCmd())
+	rootCmd.AddCommand(newCheckReleaseCmd())
+	rootCmd.AddCommand(newInventoryCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newUpgradeGuideCmd())
+	rootCmd.AddCommand(newBumpCollectorCmd())
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newHookCmd())
 
 	return rootCmd
 }
 
-func newChartMappingCmd() *cobra.Command {
-	outputPath := ""
-	historyRef := "HEAD"
+type validateOptions struct {
+	parseOptions
+	Format string
+	Watch  bool
+}
+
+func newValidateCmd() *cobra.Command {
+	opts := validateOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "chart-mapping <Chart.yaml>",
-		Short: "Generate chart to collector version mapping for one chart",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runChartMapping(args[0], outputPath, historyRef)
+		Use:   "validate <CHANGELOG.md> [<CHANGELOG.md> ...]",
+		Short: "Validate changelog formatting and tags",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Watch {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return runValidateWatch(ctx, args, opts)
+			}
+			return runValidate(args, opts)
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (defaults to stdout)")
-	cmd.Flags().StringVar(&historyRef, "ref", "HEAD", "Git ref whose history will be scanned")
+	addParseFlags(cmd, &opts.parseOptions)
+	cmd.Flags().StringVar(&opts.Format, "format", diagnosticFormatText, "Output format (text, json, sarif, github)")
+	cmd.Flags().BoolVar(&opts.Watch, "watch", false, "Validate again whenever a changelog or its "+configFileName+" is saved")
 
 	return cmd
 }
 
-func newValidateCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "validate <CHANGELOG.md> [<CHANGELOG.md> ...]",
-		Short: "Validate changelog formatting and tags",
-		Args:  cobra.MinimumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runValidate(args)
-		},
-	}
+func addDependencyFlag(cmd *cobra.Command, dependencyName *string) {
+	cmd.Flags().StringVar(dependencyName, "dependency", collectorDependencyName, "Chart dependency to track, matched by alias first and then by chart name")
+}
+
+func addParseFlags(cmd *cobra.Command, opts *parseOptions) {
+	cmd.Flags().StringVar(&opts.Dialect, "dialect", helmlog.DialectAuto, "Changelog dialect ("+strings.Join(helmlog.DialectNames(), ", ")+")")
+	cmd.Flags().StringVar(&opts.ConfigPath, "config", "", "Path to a "+configFileName+" file (defaults to the files found between the repository root and the changelog)")
+	cmd.Flags().StringSliceVar(&opts.DisabledRules, "disable-rule", nil, "Validation rule to skip; repeat or separate with commas")
+	cmd.Flags().StringVar(&opts.Today, "today", "", `Date to check release dates against, as YYYY-MM-DD or "`+todayCommit+`" for the commit date (defaults to $`+sourceDateEpochEnv+` or the clock)`)
+	cmd.Flags().StringVar(&opts.Timezone, "timezone", "", "IANA time zone that decides the current calendar day (defaults to the local time zone)")
 }
 
-func runValidate(args []string) error {
+func runValidate(args []string, opts validateOptions) error {
+	if opts.Format == "" {
+		opts.Format = diagnosticFormatText
+	}
+	if err := validateDiagnosticFormat(opts.Format); err != nil {
+		return err
+	}
+
 	totalReleases := 0
 	totalEntries := 0
+	diagnostics := []helmlog.Diagnostic{}
 
 	for _, path := range args {
-		result, err := parseFile(path)
+		// A file that cannot be read or configured is reported with the
+		// others so one bad path does not hide the problems of the rest
+		result, fileDiagnostics, err := parseFileDiagnostics(path, opts.parseOptions)
 		if err != nil {
-			return err
+			diagnostics = append(diagnostics, helmlog.Diagnostic{Path: path, Reason: strings.TrimPrefix(err.Error(), "ERROR: ")})
+			continue
 		}
 
+		diagnostics = append(diagnostics, fileDiagnostics...)
 		totalReleases += result.ReleaseCount
 		totalEntries += result.EntryCount
 	}
 
+	if opts.Format != diagnosticFormatText {
+		if err := writeDiagnostics(os.Stdout, opts.Format, len(args), diagnostics); err != nil {
+			return err
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		if opts.Format != diagnosticFormatText {
+			return errors.New(problemSummary(len(diagnostics), len(args)))
+		}
+
+		return diagnosticsError(diagnostics, len(args))
+	}
+
+	if opts.Format != diagnosticFormatText {
+		return nil
+	}
+
 	fmt.Printf("OK: %d changelog file(s) validated\n", len(args))
 	fmt.Printf("Releases parsed: %d\n", totalReleases)
 	fmt.Printf("Entries kept: %d\n", totalEntries)
@@ -145,81 +172,65 @@ func runValidate(args []string) error {
 	return nil
 }
 
+// diagnosticsError lists every diagnostic with its fix, followed by a summary
+// line, as validate prints them in text format.
+func diagnosticsError(diagnostics []helmlog.Diagnostic, files int) error {
+	messages := make([]string, 0, len(diagnostics)+1)
+	for _, d := range diagnostics {
+		message := "ERROR: " + d.Error()
+		if d.Fix != "" {
+			message += "\nFix: " + d.Fix
+		}
+		messages = append(messages, message)
+	}
+	messages = append(messages, problemSummary(len(diagnostics), files))
+
+	return errors.New(strings.Join(messages, "\n\n"))
+}
+
+func problemSummary(problems, files int) string {
+	return fmt.Sprintf("ERROR: %d problem(s) found in %d changelog file(s)", problems, files)
+}
+
+type generateOptions struct {
+	parseOptions
+	IncludeMetadata bool
+}
+
 func newGenerateCmd() *cobra.Command {
 	outputPath := ""
+	opts := generateOptions{}
 
 	cmd := &cobra.Command{
 		Use:   "generate <CHANGELOG.md>",
 		Short: "Generate deterministic changelog artifact",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
-			return runGenerate(args[0], outputPath)
+			return runGenerate(args[0], outputPath, opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().BoolVar(&opts.IncludeMetadata, "include-metadata", false, "Add the component, pull requests and authors parsed from each entry")
+	addParseFlags(cmd, &opts.parseOptions)
 
 	return cmd
 }
 
-func runGenerate(changelogPath, outputPath string) error {
-	result, err := parseFile(changelogPath)
-	if err != nil {
-		return err
-	}
-
-	sortReleasesNewestFirst(result.Log.Releases)
-
-	output, err := marshalJSON(result.Log)
-	if err != nil {
-		return err
-	}
-
-	if outputPath == "" || outputPath == "-" {
-		if _, err := os.Stdout.Write(output); err != nil {
-			return fmt.Errorf("ERROR: failed to write output: %w", err)
-		}
-		return nil
-	}
-
-	if err := os.WriteFile(outputPath, output, 0o644); err != nil {
-		return fmt.Errorf("ERROR: failed to write %s: %w", outputPath, err)
-	}
-
-	fmt.Printf("Wrote %s with %d releases and %d entries\n", outputPath, result.ReleaseCount, result.EntryCount)
-	return nil
-}
-
-func runChartMapping(chartFile, outputPath, historyRef string) error {
-	repoRoot, err := gitRepoRoot()
-	if err != nil {
-		return err
-	}
-
-	return runChartMappingWithRepoRoot(repoRoot, chartFile, outputPath, historyRef)
-}
-
-func runChartMappingWithRepoRoot(repoRoot, chartFile, outputPath, historyRef string) error {
-	chartFile, err := repoRelativeChartFile(repoRoot, chartFile)
+func runGenerate(changelogPath, outputPath string, opts generateOptions) error {
+	result, err := parseFile(changelogPath, opts.parseOptions)
 	if err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(filepath.Join(repoRoot, chartFile)); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("ERROR: chart file not found: %s", chartFile)
-		}
-		return fmt.Errorf("ERROR: failed to access chart file %s: %w", chartFile, err)
-	}
-
-	result, err := buildChartMapping(repoRoot, chartFile, historyRef)
-	if err != nil {
-		return err
+	helmlog.Sort(&result.Log)
+	if opts.IncludeMetadata {
+		result.Log.ParseMetadata()
 	}
 
-	output, err := marshalChartMappingJSON(result)
+	output, err := helmlog.Marshal(result.Log)
 	if err != nil {
-		return err
+		return fmt.Errorf("ERROR: %w", err)
 	}
 
 	if outputPath == "" || outputPath == "-" {
@@ -233,8 +244,7 @@ func runChartMappingWithRepoRoot(repoRoot, chartFile, outputPath, historyRef str
 		return fmt.Errorf("ERROR: failed to write %s: %w", outputPath, err)
 	}
 
-	fmt.Printf("Wrote %s with %d mappings\n", outputPath, len(result.Mappings))
-
+	fmt.Printf("Wrote %s with %d releases and %d entries\n", outputPath, result.ReleaseCount, result.EntryCount)
 	return nil
 }
 
@@ -260,66 +270,6 @@ func repoRelativeChartFile(repoRoot, chartFile string) (string, error) {
 	return chartFile, nil
 }
 
-func buildChartMapping(repoRoot, chartFile, historyRef string) (chartMappingResult, error) {
-	hashes, err := gitHistoryHashesForPath(repoRoot, historyRef, chartFile)
-	if err != nil {
-		return chartMappingResult{}, err
-	}
-
-	result := chartMappingResult{Mappings: make([]chartMappingEntry, 0)}
-	deduplicationMap := map[string]string{}
-	orderKeeper := []string{}
-	for _, hash := range hashes {
-		if hash == "" {
-			continue
-		}
-
-		chartSnapshot, err := runGitCommand(repoRoot, "show", fmt.Sprintf("%s:%s", hash, chartFile))
-		if err != nil {
-			continue
-		}
-
-		chartVersion, collectorVersion := extractVersionsFromChart(chartSnapshot)
-		if chartVersion == "" || collectorVersion == "" {
-			continue
-		}
-
-		if _, ok := deduplicationMap[chartVersion]; !ok {
-			orderKeeper = append(orderKeeper, chartVersion)
-			deduplicationMap[chartVersion] = collectorVersion
-			continue
-		}
-	}
-
-	for _, chartVersion := range orderKeeper {
-		result.Mappings = append(result.Mappings, chartMappingEntry{
-			ChartVersion:          chartVersion,
-			CollectorChartVersion: deduplicationMap[chartVersion],
-		})
-	}
-
-	return result, nil
-}
-
-func gitHistoryHashesForPath(repoRoot, historyRef, chartFile string) ([]string, error) {
-	historyRef = strings.TrimSpace(historyRef)
-	if historyRef == "" {
-		historyRef = "HEAD"
-	}
-
-	hashesOutput, err := runGitCommand(repoRoot, "log", historyRef, "--format=%H", "--", chartFile)
-	if err != nil {
-		return nil, fmt.Errorf("ERROR: failed to read git history for %s at ref %q: %w", chartFile, historyRef, err)
-	}
-
-	hashes := strings.Split(strings.TrimSpace(hashesOutput), "\n")
-	for i := range hashes {
-		hashes[i] = strings.TrimSpace(hashes[i])
-	}
-
-	return hashes, nil
-}
-
 func gitRepoRoot() (string, error) {
 	root, err := runGitCommand("", "rev-parse", "--show-toplevel")
 	if err != nil {
@@ -347,370 +297,105 @@ func runGitCommand(workdir string, args ...string) (string, error) {
 	return string(output), nil
 }
 
-func extractVersionsFromChart(chartContent string) (string, string) {
-	lines := strings.Split(chartContent, "\n")
-	chartVersion := ""
-	collectorVersion := ""
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			continue
-		}
-
-		indent := len(line) - len(strings.TrimLeft(line, " \t"))
-		if indent == 0 && strings.HasPrefix(trimmed, "version:") {
-			chartVersion = normalizeYAMLScalar(strings.TrimSpace(strings.TrimPrefix(trimmed, "version:")))
-			break
-		}
-	}
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if !strings.HasPrefix(trimmed, "- name:") {
-			continue
-		}
-
-		name := strings.TrimSpace(strings.TrimPrefix(trimmed, "- name:"))
-		if name != "opentelemetry-collector" {
-			continue
-		}
-
-		depIndent := len(line) - len(strings.TrimLeft(line, " \t"))
-		for j := i + 1; j < len(lines); j++ {
-			nextLine := lines[j]
-			nextTrimmed := strings.TrimSpace(nextLine)
-			if nextTrimmed == "" {
-				continue
-			}
-
-			nextIndent := len(nextLine) - len(strings.TrimLeft(nextLine, " \t"))
-			if nextIndent <= depIndent {
-				break
-			}
-
-			if strings.HasPrefix(nextTrimmed, "version:") {
-				collectorVersion = normalizeYAMLScalar(strings.TrimSpace(strings.TrimPrefix(nextTrimmed, "version:")))
-				break
-			}
-		}
-
-		break
+func parseFile(path string, opts parseOptions) (parseResult, error) {
+	result, diagnostics, err := parseFileDiagnostics(path, opts)
+	if err != nil {
+		return parseResult{}, err
 	}
-
-	return chartVersion, collectorVersion
-}
-
-func normalizeYAMLScalar(value string) string {
-	value = strings.TrimSpace(value)
-	if len(value) >= 2 {
-		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
-			return value[1 : len(value)-1]
-		}
+	if len(diagnostics) > 0 {
+		return parseResult{}, diagnosticError(diagnostics[0])
 	}
 
-	return value
+	return result, nil
 }
 
-func marshalChartMappingJSON(mapping chartMappingResult) ([]byte, error) {
-	output, err := json.MarshalIndent(mapping, "", "  ")
+// parseFileDiagnostics parses a changelog file and reports every violation
+// instead of stopping at the first one. The error is only set when the file
+// cannot be read or its configuration is invalid.
+func parseFileDiagnostics(path string, opts parseOptions) (parseResult, []helmlog.Diagnostic, error) {
+	opts, err := opts.withConfig(path)
 	if err != nil {
-		return nil, fmt.Errorf("ERROR: failed to encode json: %w", err)
+		return parseResult{}, nil, err
 	}
 
-	output = append(output, '\n')
-	return output, nil
-}
-
-func parseFile(path string) (parseResult, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return parseResult{}, fmt.Errorf("ERROR: failed to open %s: %w", path, err)
+		return parseResult{}, nil, fmt.Errorf("ERROR: failed to open %s: %w", path, err)
 	}
 	defer f.Close()
 
-	return parseMarkdown(f)
-}
-
-func parseMarkdown(r io.Reader) (parseResult, error) {
-	result := parseResult{}
-
-	scanner := bufio.NewScanner(r)
-	lineNumber := 0
-	currentRelease := -1
-
-	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
-		indent := len(line) - len(strings.TrimLeft(line, " \t"))
-		leftTrimmed := strings.TrimLeft(line, " \t")
-		trimmed := strings.TrimSpace(line)
-
-		if trimmed == "" {
-			continue
-		}
-
-		if matches := releaseHeaderPattern.FindStringSubmatch(trimmed); matches != nil {
-			if err := validateReleaseDate(matches[2]); err != nil {
-				return parseResult{}, parseError{
-					Reason: err.Error(),
-					Line:   line,
-				}
-			}
-
-			result.Log.Releases = append(result.Log.Releases, helmlog.Release{Version: matches[1], Date: matches[2]})
-			currentRelease = len(result.Log.Releases) - 1
-			result.ReleaseCount++
-			continue
-		}
-
-		if strings.HasPrefix(trimmed, "### ") {
-			return parseResult{}, parseError{
-				ReleaseVersion: releaseVersion(result.Log.Releases, currentRelease),
-				ReleaseDate:    releaseDate(result.Log.Releases, currentRelease),
-				Reason:         `release header must match "### vX.Y.Z / YYYY-MM-DD"`,
-				Line:           line,
-			}
-		}
-
-		if strings.HasPrefix(trimmed, "#### Changes from opentelemetry-collector") {
-			continue
-		}
-
-		if !strings.HasPrefix(leftTrimmed, "- ") {
-			continue
-		}
-
-		if indent > 0 {
-			if currentRelease < 0 || len(result.Log.Releases[currentRelease].Entries) == 0 {
-				return parseResult{}, parseError{
-					ReleaseVersion: releaseVersion(result.Log.Releases, currentRelease),
-					ReleaseDate:    releaseDate(result.Log.Releases, currentRelease),
-					Reason:         "sublevel entry must follow a top-level tagged entry",
-					Line:           line,
-				}
-			}
-
-			sublevelText := strings.TrimSpace(strings.TrimPrefix(leftTrimmed, "- "))
-			if sublevelText == "" {
-				return parseResult{}, parseError{
-					ReleaseVersion: result.Log.Releases[currentRelease].Version,
-					ReleaseDate:    result.Log.Releases[currentRelease].Date,
-					Reason:         "sublevel entry text must not be empty",
-					Line:           line,
-				}
-			}
-
-			last := len(result.Log.Releases[currentRelease].Entries) - 1
-			result.Log.Releases[currentRelease].Entries[last].Text += "\n- " + sublevelText
-			continue
-		}
-
-		if strings.Contains(trimmed, droppedEntryPhrase) {
-			continue
-		}
-
-		if currentRelease < 0 {
-			return parseResult{}, parseError{
-				Reason: fmt.Sprintf("entry found before first release header at line %d", lineNumber),
-				Line:   line,
-			}
-		}
-
-		tags, text, ok := parseEntry(trimmed)
-		if !ok {
-			return parseResult{}, parseError{
-				ReleaseVersion: result.Log.Releases[currentRelease].Version,
-				ReleaseDate:    result.Log.Releases[currentRelease].Date,
-				Reason:         `entry must match "- [Tag] text..."`,
-				Line:           line,
-			}
-		}
-
-		for _, tag := range tags {
-			if err := validateTag(tag); err != nil {
-				return parseResult{}, parseError{
-					ReleaseVersion: result.Log.Releases[currentRelease].Version,
-					ReleaseDate:    result.Log.Releases[currentRelease].Date,
-					Reason:         err.Error(),
-					Line:           line,
-				}
-			}
-		}
-
-		if text == "" {
-			return parseResult{}, parseError{
-				ReleaseVersion: result.Log.Releases[currentRelease].Version,
-				ReleaseDate:    result.Log.Releases[currentRelease].Date,
-				Reason:         "entry text must not be empty",
-				Line:           line,
-			}
-		}
-
-		result.Log.Releases[currentRelease].Entries = append(result.Log.Releases[currentRelease].Entries, helmlog.Entry{
-			Tag:  normalizeTag(selectPrimaryTag(tags)),
-			Text: text,
-		})
-		result.EntryCount++
-	}
-
-	if err := scanner.Err(); err != nil {
-		return parseResult{}, fmt.Errorf("ERROR: failed reading changelog: %w", err)
-	}
-
-	if result.ReleaseCount == 0 {
-		return parseResult{}, errors.New("ERROR: no releases found")
-	}
-
-	return result, nil
-}
-
-func validateTag(tag string) error {
-	normalized := strings.ToLower(strings.TrimSpace(tag))
-	if !allowedTags[normalized] {
-		return fmt.Errorf("unknown tag %q", "["+tag+"]")
+	result, diagnostics, err := parseMarkdownDiagnostics(f, opts)
+	for i := range diagnostics {
+		diagnostics[i].Path = path
 	}
 
-	return nil
+	return result, diagnostics, err
 }
 
-func normalizeTag(tag string) string {
-	normalized := strings.ToLower(strings.TrimSpace(tag))
-	switch normalized {
-	case "feat", "feature":
-		return "Feat"
-	case "fix", "bug":
-		return "Fix"
-	case "change", ":warning: change":
-		return "Change"
-	case "breaking", ":warning: breaking change":
-		return "Breaking"
-	case "chore":
-		return "Chore"
-	case "revert":
-		return "Revert"
-	case "update":
-		return "Update"
-	case "docs":
-		return "Docs"
-	default:
-		return tag
-	}
+func parseMarkdown(r io.Reader) (parseResult, error) {
+	return parseMarkdownDialect(r, helmlog.DialectAuto)
 }
 
-func parseEntry(line string) ([]string, string, bool) {
-	rest := strings.TrimSpace(strings.TrimPrefix(line, "- "))
-	tags := make([]string, 0, 1)
-
-	for strings.HasPrefix(rest, "[") {
-		end := strings.IndexByte(rest, ']')
-		if end <= 1 {
-			return nil, "", false
-		}
-
-		tag := strings.TrimSpace(rest[1:end])
-		if tag == "" {
-			return nil, "", false
-		}
-
-		tags = append(tags, tag)
-		rest = strings.TrimSpace(rest[end+1:])
+func parseMarkdownDialect(r io.Reader, dialectName string) (parseResult, error) {
+	result, diagnostics, err := parseMarkdownDiagnostics(r, parseOptions{Dialect: dialectName})
+	if err != nil {
+		return parseResult{}, err
 	}
-
-	if len(tags) == 0 || rest == "" {
-		return nil, "", false
+	if len(diagnostics) > 0 {
+		return parseResult{}, diagnosticError(diagnostics[0])
 	}
 
-	return tags, rest, true
-}
-
-func selectPrimaryTag(tags []string) string {
-	for i := len(tags) - 1; i >= 0; i-- {
-		normalized := strings.ToLower(strings.TrimSpace(tags[i]))
-		if normalized != ":warning: change" && normalized != ":warning: breaking change" {
-			return tags[i]
-		}
-	}
-
-	return tags[len(tags)-1]
+	return result, nil
 }
 
-func releaseVersion(releases []helmlog.Release, index int) string {
-	if index < 0 || index >= len(releases) {
-		return ""
+// parseMarkdownDiagnostics parses with helmlog.Parse and drops the
+// diagnostics that are disabled by flag or configuration. The error is only
+// set when the content cannot be read, the dialect is unknown or the
+// reference date cannot be determined.
+func parseMarkdownDiagnostics(r io.Reader, opts parseOptions) (parseResult, []helmlog.Diagnostic, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return parseResult{}, nil, fmt.Errorf("ERROR: failed reading changelog: %w", err)
 	}
 
-	return releases[index].Version
-}
-
-func releaseDate(releases []helmlog.Release, index int) string {
-	if index < 0 || index >= len(releases) {
-		return ""
+	dialect, err := resolveDialect(opts.dialectName(), strings.Split(string(content), "\n"))
+	if err != nil {
+		return parseResult{}, nil, err
 	}
 
-	return releases[index].Date
-}
-
-func sortReleasesNewestFirst(releases []helmlog.Release) {
-	sort.SliceStable(releases, func(i, j int) bool {
-		if releases[i].Date != releases[j].Date {
-			return releases[i].Date > releases[j].Date
-		}
-
-		vi := parseVersion(releases[i].Version)
-		vj := parseVersion(releases[j].Version)
-		if vi[0] != vj[0] {
-			return vi[0] > vj[0]
-		}
-		if vi[1] != vj[1] {
-			return vi[1] > vj[1]
-		}
-		if vi[2] != vj[2] {
-			return vi[2] > vj[2]
-		}
-
-		return i < j
-	})
-}
-
-func parseVersion(version string) [3]int {
-	stripped := strings.TrimPrefix(version, "v")
-	parts := strings.Split(stripped, ".")
-	var out [3]int
-	for i := 0; i < len(parts) && i < 3; i++ {
-		v, err := strconv.Atoi(parts[i])
-		if err != nil {
-			return out
-		}
-		out[i] = v
+	location, err := opts.location()
+	if err != nil {
+		return parseResult{}, nil, err
 	}
-
-	return out
-}
-
-func validateReleaseDate(date string) error {
-	releaseDate, err := time.Parse("2006-01-02", date)
+	now, err := opts.referenceTime(location)
 	if err != nil {
-		return fmt.Errorf("invalid release date %q", date)
+		return parseResult{}, nil, err
 	}
 
-	today, err := time.Parse("2006-01-02", nowFunc().In(time.Local).Format("2006-01-02"))
-	if err != nil {
-		return fmt.Errorf("failed to determine current date: %w", err)
+	log, diagnostics := helmlog.Parse(bytes.NewReader(content), helmlog.Options{
+		Dialect:            dialect.Name,
+		Taxonomy:           opts.tagTaxonomy(),
+		Now:                func() time.Time { return now },
+		Location:           location,
+		InitialDevelopment: opts.config().InitialDevelopment,
+	})
+
+	result := parseResult{Dialect: dialect.Name, Log: log, ReleaseCount: len(log.Releases)}
+	for _, release := range log.Releases {
+		result.EntryCount += len(release.Entries)
 	}
 
-	if releaseDate.After(today) {
-		return fmt.Errorf("release date must not be in the future: %s", date)
+	enabled := diagnostics[:0]
+	for _, d := range diagnostics {
+		if opts.reportable(d) {
+			enabled = append(enabled, d)
+		}
 	}
 
-	return nil
+	return result, enabled, nil
 }
 
-func marshalJSON(log helmlog.Changelog) ([]byte, error) {
-	output, err := json.MarshalIndent(log, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("ERROR: failed to encode json: %w", err)
-	}
-	output = append(output, '\n')
-	return output, nil
+// diagnosticError reports a diagnostic as a command error.
+func diagnosticError(d helmlog.Diagnostic) error {
+	return fmt.Errorf("ERROR: %w", d)
 }
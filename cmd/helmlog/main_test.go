@@ -43,6 +43,61 @@ func TestParseMarkdownSuccess(t *testing.T) {
 	}
 }
 
+func TestParseMarkdownGroupsUpstreamChanges(t *testing.T) {
+	input := `### v1.2.3 / 2026-02-10
+- [Change] Update Helm dependency
+- [Chore] Bump chart dependency to opentelemetry-collector 0.129.2
+
+#### Changes from opentelemetry-collector 0.129.2:
+- [Fix] Keep this line
+  - with its detail
+
+### v1.2.2 / 2026-01-01
+- [Feat] Add deterministic parser
+`
+
+	result, err := parseMarkdown(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	release := result.Log.Releases[0]
+	wantEntries := []helmlog.Entry{
+		{Tag: "Change", Text: "Update Helm dependency", Origin: helmlog.OriginChart},
+		{Tag: "Fix", Text: "Keep this line\n- with its detail", Origin: "opentelemetry-collector"},
+	}
+	if !reflect.DeepEqual(release.Entries, wantEntries) {
+		t.Fatalf("entries = %#v, want %#v", release.Entries, wantEntries)
+	}
+
+	wantUpstream := []helmlog.UpstreamChangelog{
+		{Chart: "opentelemetry-collector", Version: "0.129.2", Entries: wantEntries[1:]},
+	}
+	if !reflect.DeepEqual(release.Upstream, wantUpstream) {
+		t.Fatalf("upstream = %#v, want %#v", release.Upstream, wantUpstream)
+	}
+
+	if got := result.Log.Releases[1].Upstream; got != nil {
+		t.Fatalf("second release upstream = %#v, want nil", got)
+	}
+}
+
+func TestParseMarkdownInvalidUpstreamHeader(t *testing.T) {
+	input := `### v1.2.3 / 2026-02-10
+#### Changes from opentelemetry-collector latest:
+- [Fix] Keep this line
+`
+
+	_, err := parseMarkdown(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected parse error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "upstream header must match") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestParseMarkdownUnknownTag(t *testing.T) {
 	input := `### v1.2.3 / 2026-02-10
 - [Improvement] Reduce memory usage
@@ -76,8 +131,12 @@ func TestParseMarkdownEntryWithWarningPrefixTag(t *testing.T) {
 		t.Fatalf("EntryCount = %d, want 1", result.EntryCount)
 	}
 
-	if got := result.Log.Releases[0].Entries[0].Tag; got != "Feat" {
-		t.Fatalf("tag = %q, want %q", got, "Feat")
+	entry := result.Log.Releases[0].Entries[0]
+	if entry.Tag != "Feat" {
+		t.Fatalf("tag = %q, want %q", entry.Tag, "Feat")
+	}
+	if !entry.Warning || entry.Breaking {
+		t.Fatalf("Warning = %t, Breaking = %t, want the warning qualifier kept", entry.Warning, entry.Breaking)
 	}
 }
 
@@ -96,23 +155,44 @@ func TestParseMarkdownInvalidReleaseHeader(t *testing.T) {
 	}
 }
 
-func TestSortReleasesNewestFirst(t *testing.T) {
-	releases := []helmlog.Release{
-		{Version: "v1.1.0", Date: "2025-01-10"},
-		{Version: "v1.2.0", Date: "2026-01-01"},
-		{Version: "v1.3.0", Date: "2026-01-01"},
-	}
+func TestRunGenerateIncludeMetadata(t *testing.T) {
+	dir := t.TempDir()
+	changelogPath := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, changelogPath, `### v0.0.2 / 2026-01-02
+- [Feat] spanMetrics: add `+"`seriesExpiration`"+` option, thanks @jane-doe (#1234)
 
-	sortReleasesNewestFirst(releases)
+#### Changes from opentelemetry-collector 0.101.0:
+- [Fix] k8sattributes: fix pod lookup (#99)
+`)
 
-	if releases[0].Version != "v1.3.0" {
-		t.Fatalf("first release = %q, want v1.3.0", releases[0].Version)
-	}
-	if releases[1].Version != "v1.2.0" {
-		t.Fatalf("second release = %q, want v1.2.0", releases[1].Version)
-	}
-	if releases[2].Version != "v1.1.0" {
-		t.Fatalf("third release = %q, want v1.1.0", releases[2].Version)
+	for _, include := range []bool{false, true} {
+		outputPath := filepath.Join(dir, "changelog.json")
+		if err := runGenerate(changelogPath, outputPath, generateOptions{IncludeMetadata: include}); err != nil {
+			t.Fatalf("runGenerate(include=%t) error = %v", include, err)
+		}
+
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		if !include {
+			if strings.Contains(string(content), "pull_requests") {
+				t.Fatalf("metadata present without --include-metadata:\n%s", content)
+			}
+			continue
+		}
+
+		var log helmlog.Changelog
+		if err := json.Unmarshal(content, &log); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		entry := log.Releases[0].Entries[0]
+		if entry.Component != "spanMetrics" || !reflect.DeepEqual(entry.PullRequests, []int{1234}) || !reflect.DeepEqual(entry.Authors, []string{"jane-doe"}) {
+			t.Fatalf("entry = %#v, want parsed metadata", entry)
+		}
+		if upstream := log.Releases[0].Upstream[0].Entries[0]; upstream.Component != "k8sattributes" {
+			t.Fatalf("upstream entry = %#v, want parsed metadata", upstream)
+		}
 	}
 }
 
@@ -184,33 +264,6 @@ func TestParseMarkdownCurrentReleaseDatePasses(t *testing.T) {
 	}
 }
 
-func TestValidateReleaseDateUsesLocalCalendarDay(t *testing.T) {
-	originalNowFunc := nowFunc
-	originalLocal := time.Local
-	t.Cleanup(func() {
-		nowFunc = originalNowFunc
-		time.Local = originalLocal
-	})
-
-	localZone := time.FixedZone("UTC+2", 2*60*60)
-	time.Local = localZone
-	nowFunc = func() time.Time {
-		return time.Date(2026, 2, 10, 0, 30, 0, 0, localZone)
-	}
-
-	if err := validateReleaseDate("2026-02-10"); err != nil {
-		t.Fatalf("validateReleaseDate() error = %v, want nil", err)
-	}
-
-	err := validateReleaseDate("2026-02-11")
-	if err == nil {
-		t.Fatal("expected future-date error, got nil")
-	}
-	if !strings.Contains(err.Error(), "release date must not be in the future") {
-		t.Fatalf("unexpected error: %v", err)
-	}
-}
-
 func TestExtractVersionsFromChart(t *testing.T) {
 	chart := `apiVersion: v2
 name: linux-standalone
@@ -223,7 +276,10 @@ dependencies:
     repository: https://cgx.jfrog.io/artifactory/coralogix-charts-virtual
 `
 
-	chartVersion, collectorVersion := extractVersionsFromChart(chart)
+	chartVersion, collectorVersion, err := extractVersionsFromChart(chart, collectorDependencyName)
+	if err != nil {
+		t.Fatalf("extractVersionsFromChart() error = %v", err)
+	}
 	if chartVersion != "0.0.18" {
 		t.Fatalf("chartVersion = %q, want %q", chartVersion, "0.0.18")
 	}
@@ -244,7 +300,10 @@ dependencies:
     repository: https://cgx.jfrog.io/artifactory/coralogix-charts-virtual
 `
 
-	chartVersion, collectorVersion := extractVersionsFromChart(chart)
+	chartVersion, collectorVersion, err := extractVersionsFromChart(chart, collectorDependencyName)
+	if err != nil {
+		t.Fatalf("extractVersionsFromChart() error = %v", err)
+	}
 	if chartVersion != "0.0.18" {
 		t.Fatalf("chartVersion = %q, want %q", chartVersion, "0.0.18")
 	}
@@ -262,7 +321,10 @@ dependencies:
     version: "1.2.3"
 `
 
-	chartVersion, collectorVersion := extractVersionsFromChart(chart)
+	chartVersion, collectorVersion, err := extractVersionsFromChart(chart, collectorDependencyName)
+	if err != nil {
+		t.Fatalf("extractVersionsFromChart() error = %v", err)
+	}
 	if chartVersion != "0.0.18" {
 		t.Fatalf("chartVersion = %q, want %q", chartVersion, "0.0.18")
 	}
@@ -274,8 +336,8 @@ dependencies:
 func TestMarshalChartMappingJSON(t *testing.T) {
 	mapping := chartMappingResult{
 		Mappings: []chartMappingEntry{
-			{ChartVersion: "0.0.18", CollectorChartVersion: "0.130.4"},
-			{ChartVersion: "0.0.17", CollectorChartVersion: "0.129.2"},
+			collectorMapping("0.0.18", "0.130.4"),
+			collectorMapping("0.0.17", "0.129.2"),
 		},
 	}
 
@@ -301,34 +363,34 @@ func TestMarshalChartMappingJSON(t *testing.T) {
 func TestBuildChartMappingLimitsHistoryToTargetRef(t *testing.T) {
 	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
 
-	mainResult, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), "HEAD")
+	mainResult, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), chartMappingOptions{HistoryRef: "HEAD", Dependency: collectorDependencyName})
 	if err != nil {
 		t.Fatalf("buildChartMapping(HEAD) error = %v", err)
 	}
 
 	wantMain := []chartMappingEntry{
-		{ChartVersion: "0.0.2", CollectorChartVersion: "0.101.0"},
-		{ChartVersion: "0.0.1", CollectorChartVersion: "0.100.0"},
+		collectorMapping("0.0.2", "0.101.0"),
+		collectorMapping("0.0.1", "0.100.0"),
 	}
-	if !reflect.DeepEqual(mainResult.Mappings, wantMain) {
-		t.Fatalf("HEAD mappings = %#v, want %#v", mainResult.Mappings, wantMain)
+	if !reflect.DeepEqual(withoutCommits(mainResult.Mappings), wantMain) {
+		t.Fatalf("HEAD mappings = %#v, want %#v", withoutCommits(mainResult.Mappings), wantMain)
 	}
 }
 
 func TestBuildChartMappingSupportsExplicitRef(t *testing.T) {
 	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-linux-standalone", "Chart.yaml"))
 
-	featureResult, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), "feature")
+	featureResult, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), chartMappingOptions{HistoryRef: "feature", Dependency: collectorDependencyName})
 	if err != nil {
 		t.Fatalf("buildChartMapping(feature) error = %v", err)
 	}
 
 	wantFeature := []chartMappingEntry{
-		{ChartVersion: "0.9.9", CollectorChartVersion: "9.9.9"},
-		{ChartVersion: "0.0.1", CollectorChartVersion: "0.100.0"},
+		collectorMapping("0.9.9", "9.9.9"),
+		collectorMapping("0.0.1", "0.100.0"),
 	}
-	if !reflect.DeepEqual(featureResult.Mappings, wantFeature) {
-		t.Fatalf("feature mappings = %#v, want %#v", featureResult.Mappings, wantFeature)
+	if !reflect.DeepEqual(withoutCommits(featureResult.Mappings), wantFeature) {
+		t.Fatalf("feature mappings = %#v, want %#v", withoutCommits(featureResult.Mappings), wantFeature)
 	}
 }
 
@@ -338,17 +400,17 @@ func TestBuildChartMappingKeepsNewestDuplicateChartVersion(t *testing.T) {
 	writeTestChart(t, repoRoot, "0.0.2", "0.099.0")
 	runTestGit(t, repoRoot, "commit", "-am", "main rollback collector for same chart version")
 
-	result, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), "HEAD")
+	result, err := buildChartMapping(repoRoot, filepath.Join("otel-linux-standalone", "Chart.yaml"), chartMappingOptions{HistoryRef: "HEAD", Dependency: collectorDependencyName})
 	if err != nil {
 		t.Fatalf("buildChartMapping(HEAD) error = %v", err)
 	}
 
 	want := []chartMappingEntry{
-		{ChartVersion: "0.0.2", CollectorChartVersion: "0.099.0"},
-		{ChartVersion: "0.0.1", CollectorChartVersion: "0.100.0"},
+		collectorMapping("0.0.2", "0.099.0"),
+		collectorMapping("0.0.1", "0.100.0"),
 	}
-	if !reflect.DeepEqual(result.Mappings, want) {
-		t.Fatalf("HEAD mappings after duplicate chart version = %#v, want %#v", result.Mappings, want)
+	if !reflect.DeepEqual(withoutCommits(result.Mappings), want) {
+		t.Fatalf("HEAD mappings after duplicate chart version = %#v, want %#v", withoutCommits(result.Mappings), want)
 	}
 }
 
@@ -357,7 +419,7 @@ func TestRunChartMappingSupportsArbitraryChartPath(t *testing.T) {
 	t.Chdir(repoRoot)
 
 	outputPath := filepath.Join(repoRoot, "otel_ecs_ec2_chart_versions_map.json")
-	if err := runChartMapping(filepath.Join(repoRoot, "otel-ecs-ec2", "Chart.yaml"), outputPath, "HEAD"); err != nil {
+	if err := runChartMapping(filepath.Join(repoRoot, "otel-ecs-ec2", "Chart.yaml"), chartMappingOptions{OutputPath: outputPath, HistoryRef: "HEAD", Dependency: collectorDependencyName}); err != nil {
 		t.Fatalf("runChartMapping() error = %v", err)
 	}
 
@@ -372,11 +434,11 @@ func TestRunChartMappingSupportsArbitraryChartPath(t *testing.T) {
 	}
 
 	want := []chartMappingEntry{
-		{ChartVersion: "0.0.2", CollectorChartVersion: "0.101.0"},
-		{ChartVersion: "0.0.1", CollectorChartVersion: "0.100.0"},
+		collectorMapping("0.0.2", "0.101.0"),
+		collectorMapping("0.0.1", "0.100.0"),
 	}
-	if !reflect.DeepEqual(got.Mappings, want) {
-		t.Fatalf("mappings = %#v, want %#v", got.Mappings, want)
+	if !reflect.DeepEqual(withoutCommits(got.Mappings), want) {
+		t.Fatalf("mappings = %#v, want %#v", withoutCommits(got.Mappings), want)
 	}
 }
 
@@ -465,3 +527,53 @@ func runTestGit(t *testing.T, repoRoot string, args ...string) string {
 
 	return string(output)
 }
+
+func TestBuildChartMappingTracksDependencyByAlias(t *testing.T) {
+	repoRoot := setupChartMappingTestRepo(t, filepath.Join("otel-ecs-ec2", "Chart.yaml"))
+
+	chart := `apiVersion: v2
+name: ecs-ec2-integration
+version: 0.0.3
+dependencies:
+  - name: opentelemetry-collector
+    alias: opentelemetry-ebpf-profiler
+    version: "0.101.0"
+  - name: opentelemetry-collector
+    alias: opentelemetry-agent
+    version: "0.102.0"
+`
+	writeTestFile(t, filepath.Join(repoRoot, "otel-ecs-ec2", "Chart.yaml"), chart)
+	runTestGit(t, repoRoot, "commit", "-am", "main add agent alias")
+
+	result, err := buildChartMapping(repoRoot, filepath.Join("otel-ecs-ec2", "Chart.yaml"), chartMappingOptions{HistoryRef: "HEAD", Dependency: "opentelemetry-agent"})
+	if err != nil {
+		t.Fatalf("buildChartMapping() error = %v", err)
+	}
+
+	dependencies := []chartMappingDependency{
+		{Name: "opentelemetry-collector", Alias: "opentelemetry-ebpf-profiler", Version: "0.101.0"},
+		{Name: "opentelemetry-collector", Alias: "opentelemetry-agent", Version: "0.102.0"},
+	}
+
+	// Older snapshots have no opentelemetry-agent alias and are skipped.
+	want := []chartMappingEntry{
+		{ChartVersion: "0.0.3", CollectorChartVersion: "0.102.0", CollectorChanged: true, Dependencies: dependencies},
+	}
+	if !reflect.DeepEqual(withoutCommits(result.Mappings), want) {
+		t.Fatalf("mappings = %#v, want %#v", withoutCommits(result.Mappings), want)
+	}
+
+	result, err = buildChartMapping(repoRoot, filepath.Join("otel-ecs-ec2", "Chart.yaml"), chartMappingOptions{HistoryRef: "HEAD", Dependency: collectorDependencyName})
+	if err != nil {
+		t.Fatalf("buildChartMapping() error = %v", err)
+	}
+
+	want = []chartMappingEntry{
+		{ChartVersion: "0.0.3", CollectorChartVersion: "0.101.0", Dependencies: dependencies},
+		collectorMapping("0.0.2", "0.101.0"),
+		collectorMapping("0.0.1", "0.100.0"),
+	}
+	if !reflect.DeepEqual(withoutCommits(result.Mappings), want) {
+		t.Fatalf("mappings = %#v, want %#v", withoutCommits(result.Mappings), want)
+	}
+}
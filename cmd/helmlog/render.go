@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+const (
+	renderFormatMarkdown     = "markdown"
+	renderFormatHTML         = "html"
+	renderFormatReleaseNotes = "release-notes"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+var builtinTemplateFiles = map[string]string{
+	renderFormatMarkdown:     "templates/markdown.tmpl",
+	renderFormatHTML:         "templates/html.tmpl",
+	renderFormatReleaseNotes: "templates/release-notes.tmpl",
+}
+
+// tagDisplayOrder controls the order of tag groups in rendered output. Tags
+// not listed here follow in alphabetical order.
+var tagDisplayOrder = []string{"Breaking", "Feat", "Fix", "Change", "Update", "Revert", "Docs", "Chore"}
+
+type renderOptions struct {
+	parseOptions
+	Format       string
+	TemplatePath string
+	Title        string
+	From         string
+	To           string
+}
+
+// renderData is the value passed to render templates.
+type renderData struct {
+	Title    string
+	From     string
+	To       string
+	Releases []helmlog.Release
+}
+
+type tagGroup struct {
+	Tag     string
+	Entries []helmlog.Entry
+}
+
+func newRenderCmd() *cobra.Command {
+	outputPath := ""
+	opts := renderOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "render <changelog.json|CHANGELOG.md>",
+		Short: "Render a changelog artifact as Markdown, HTML or release notes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runRender(args[0], outputPath, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", renderFormatMarkdown, "Output format (markdown, html, release-notes)")
+	cmd.Flags().StringVar(&opts.TemplatePath, "template", "", "Custom Go text/template file used instead of the built-in template")
+	cmd.Flags().StringVar(&opts.Title, "title", "Changelog", "Document title")
+	cmd.Flags().StringVar(&opts.From, "from", "", "Exclusive lower bound of the version range")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Inclusive upper bound of the version range")
+	addParseFlags(cmd, &opts.parseOptions)
+
+	return cmd
+}
+
+func runRender(inputPath, outputPath string, opts renderOptions) error {
+	log, err := loadChangelog(inputPath, opts.parseOptions)
+	if err != nil {
+		return err
+	}
+
+	output, err := renderChangelog(log, opts)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" || outputPath == "-" {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("ERROR: failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, output, 0o644); err != nil {
+		return fmt.Errorf("ERROR: failed to write %s: %w", outputPath, err)
+	}
+
+	fmt.Printf("Wrote %s\n", outputPath)
+	return nil
+}
+
+// loadChangelog reads a generated JSON artifact or parses a CHANGELOG.md,
+// depending on the file extension.
+func loadChangelog(path string, opts parseOptions) (helmlog.Changelog, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		result, err := parseFile(path, opts)
+		if err != nil {
+			return helmlog.Changelog{}, err
+		}
+
+		helmlog.Sort(&result.Log)
+		return result.Log, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return helmlog.Changelog{}, fmt.Errorf("ERROR: failed to read %s: %w", path, err)
+	}
+
+	var log helmlog.Changelog
+	if err := json.Unmarshal(content, &log); err != nil {
+		return helmlog.Changelog{}, fmt.Errorf("ERROR: failed to decode %s: %w", path, err)
+	}
+
+	helmlog.Sort(&log)
+	return log, nil
+}
+
+func renderChangelog(log helmlog.Changelog, opts renderOptions) ([]byte, error) {
+	tmpl, err := loadRenderTemplate(opts.Format, opts.TemplatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := releasesInRange(log.Releases, opts.From, opts.To)
+	if err != nil {
+		return nil, err
+	}
+
+	data := renderData{
+		Title:    opts.Title,
+		From:     opts.From,
+		To:       opts.To,
+		Releases: releases,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("ERROR: failed to render template: %w", err)
+	}
+
+	output := bytes.TrimRight(buf.Bytes(), "\n")
+	output = append(output, '\n')
+	return output, nil
+}
+
+func loadRenderTemplate(format, templatePath string) (*template.Template, error) {
+	tmpl := template.New("render").Funcs(renderFuncs())
+
+	if templatePath != "" {
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: failed to read template %s: %w", templatePath, err)
+		}
+
+		parsed, err := tmpl.Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: failed to parse template %s: %w", templatePath, err)
+		}
+		return parsed, nil
+	}
+
+	file, ok := builtinTemplateFiles[format]
+	if !ok {
+		return nil, fmt.Errorf("ERROR: unknown render format %q (expected markdown, html or release-notes)", format)
+	}
+
+	content, err := builtinTemplates.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: failed to read built-in template %s: %w", file, err)
+	}
+
+	return template.Must(tmpl.Parse(string(content))), nil
+}
+
+func renderFuncs() template.FuncMap {
+	return template.FuncMap{
+		"localEntries": localEntries,
+		"groupByTag":   groupReleasesByTag,
+		"entryLines":   entryLines,
+		"bullet":       markdownBullet,
+		"bullets":      markdownBullets,
+		"plainBullets": plainMarkdownBullets,
+		"htmlEntry":    htmlEntry,
+	}
+}
+
+// releasesInRange keeps releases with from < version <= to. Empty bounds are
+// open.
+func releasesInRange(releases []helmlog.Release, from, to string) ([]helmlog.Release, error) {
+	for _, bound := range []string{from, to} {
+		if bound != "" && !versionPattern.MatchString(bound) {
+			return nil, fmt.Errorf("ERROR: invalid version %q", bound)
+		}
+	}
+
+	out := make([]helmlog.Release, 0, len(releases))
+	for _, release := range releases {
+		if from != "" && helmlog.CompareVersions(release.Version, from) <= 0 {
+			continue
+		}
+		if to != "" && helmlog.CompareVersions(release.Version, to) > 0 {
+			continue
+		}
+		out = append(out, release)
+	}
+
+	return out, nil
+}
+
+// localEntries returns the entries written for the chart itself, leaving out
+// the ones inherited from upstream changelogs.
+func localEntries(release helmlog.Release) []helmlog.Entry {
+	out := make([]helmlog.Entry, 0, len(release.Entries))
+	for _, entry := range release.Entries {
+		if entry.Origin == "" || entry.Origin == helmlog.OriginChart {
+			out = append(out, entry)
+		}
+	}
+
+	return out
+}
+
+// groupReleasesByTag aggregates the entries of all releases by tag, ordered by
+// tagDisplayOrder.
+func groupReleasesByTag(releases []helmlog.Release) []tagGroup {
+	byTag := map[string][]helmlog.Entry{}
+	for _, release := range releases {
+		for _, entry := range release.Entries {
+			byTag[entry.Tag] = append(byTag[entry.Tag], entry)
+		}
+	}
+
+	groups := make([]tagGroup, 0, len(byTag))
+	for _, tag := range orderedTags(byTag) {
+		groups = append(groups, tagGroup{Tag: tag, Entries: byTag[tag]})
+	}
+
+	return groups
+}
+
+func orderedTags(byTag map[string][]helmlog.Entry) []string {
+	tags := make([]string, 0, len(byTag))
+	seen := map[string]bool{}
+	for _, tag := range tagDisplayOrder {
+		if _, ok := byTag[tag]; ok {
+			tags = append(tags, tag)
+			seen[tag] = true
+		}
+	}
+
+	rest := make([]string, 0)
+	for tag := range byTag {
+		if !seen[tag] {
+			rest = append(rest, tag)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(tags, rest...)
+}
+
+// entryLines splits entry text into its main line followed by the text of
+// each sublevel bullet.
+func entryLines(entry helmlog.Entry) []string {
+	lines := strings.Split(entry.Text, "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], "- ")
+	}
+
+	return lines
+}
+
+// entryQualifier returns the tag written before the entry's own tag to mark
+// it as breaking or as a change to watch, such as "[Breaking]" in
+// "- [Breaking][Feat] ...". It is empty for plain entries.
+func entryQualifier(entry helmlog.Entry) string {
+	switch {
+	case entry.Breaking && entry.Tag != "Breaking" && entry.Warning:
+		return "[:warning: Breaking Change]"
+	case entry.Breaking && entry.Tag != "Breaking":
+		return "[Breaking]"
+	case entry.Warning:
+		return "[:warning: Change]"
+	}
+
+	return ""
+}
+
+func markdownBullet(entry helmlog.Entry) string {
+	lines := entryLines(entry)
+
+	var b strings.Builder
+	b.WriteString("- " + entryQualifier(entry) + "[" + entry.Tag + "] " + lines[0])
+	for _, sub := range lines[1:] {
+		b.WriteString("\n  - " + sub)
+	}
+
+	return b.String()
+}
+
+func markdownBullets(entries []helmlog.Entry) string {
+	bullets := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		bullets = append(bullets, markdownBullet(entry))
+	}
+
+	return strings.Join(bullets, "\n")
+}
+
+// plainMarkdownBullets renders entries without their tag, for output that is
+// already grouped by tag.
+func plainMarkdownBullets(entries []helmlog.Entry) string {
+	bullets := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines := entryLines(entry)
+		bullet := "- " + lines[0]
+		if qualifier := entryQualifier(entry); qualifier != "" {
+			bullet = "- " + qualifier + " " + lines[0]
+		}
+		for _, sub := range lines[1:] {
+			bullet += "\n  - " + sub
+		}
+		bullets = append(bullets, bullet)
+	}
+
+	return strings.Join(bullets, "\n")
+}
+
+func htmlEntry(entry helmlog.Entry) string {
+	lines := entryLines(entry)
+	class := "tag tag-" + strings.ToLower(entry.Tag)
+
+	var b strings.Builder
+	b.WriteString(`<li><span class="` + html.EscapeString(class) + `">` + html.EscapeString(entry.Tag) + "</span> ")
+	if qualifier := entryQualifier(entry); qualifier != "" {
+		b.WriteString(html.EscapeString(qualifier) + " ")
+	}
+	b.WriteString(html.EscapeString(lines[0]))
+	if len(lines) > 1 {
+		b.WriteString("<ul>")
+		for _, sub := range lines[1:] {
+			b.WriteString("<li>" + html.EscapeString(sub) + "</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	b.WriteString("</li>")
+
+	return b.String()
+}
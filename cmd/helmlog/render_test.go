@@ -0,0 +1,210 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+const renderTestChangelog = `# Changelog
+
+### v1.2.3 / 2026-02-10
+
+- [Change] Update Helm dependency
+- [Chore] Bump chart dependency to opentelemetry-collector 0.129.2
+
+#### Changes from opentelemetry-collector 0.129.2:
+- [Fix] Keep <this> line
+  - with its detail
+
+### v1.2.2 / 2026-01-01
+
+- [Feat] Add deterministic parser
+
+### v1.2.1 / 2025-12-01
+
+- [Breaking] Drop legacy preset
+`
+
+func TestRenderMarkdownRoundTrips(t *testing.T) {
+	parsed, err := parseMarkdown(strings.NewReader(renderTestChangelog))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	output, err := renderChangelog(parsed.Log, renderOptions{Format: renderFormatMarkdown, Title: "Changelog"})
+	if err != nil {
+		t.Fatalf("renderChangelog() error = %v", err)
+	}
+
+	reparsed, err := parseMarkdown(strings.NewReader(string(output)))
+	if err != nil {
+		t.Fatalf("parseMarkdown(rendered) error = %v\n%s", err, output)
+	}
+
+	if !reflect.DeepEqual(reparsed.Log, parsed.Log) {
+		t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", reparsed.Log, parsed.Log)
+	}
+
+	if !strings.Contains(string(output), "#### Changes from opentelemetry-collector 0.129.2:\n- [Fix] Keep <this> line\n  - with its detail\n") {
+		t.Fatalf("rendered markdown is missing the upstream section:\n%s", output)
+	}
+}
+
+// TestRenderMarkdownGolden pins the markdown rendering of the helm golden
+// changelog, including its breaking and :warning: entries. Run
+// go test ./cmd/helmlog -run TestRenderMarkdownGolden -update after an
+// intended change to the output.
+func TestRenderMarkdownGolden(t *testing.T) {
+	log, err := loadChangelog(filepath.Join("..", "..", "pkg", "helmlog", "testdata", "golden", "helm.md"), parseOptions{})
+	if err != nil {
+		t.Fatalf("loadChangelog() error = %v", err)
+	}
+
+	output, err := renderChangelog(log, renderOptions{Format: renderFormatMarkdown, Title: "Changelog"})
+	if err != nil {
+		t.Fatalf("renderChangelog() error = %v", err)
+	}
+
+	goldenPath := filepath.Join("testdata", "golden", "helm.md")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			t.Fatalf("os.MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(goldenPath, output, 0o644); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if string(output) != string(golden) {
+		t.Fatalf("rendered markdown differs from %s:\n%s", goldenPath, output)
+	}
+
+	reparsed, err := parseMarkdown(strings.NewReader(string(output)))
+	if err != nil {
+		t.Fatalf("parseMarkdown(rendered) error = %v\n%s", err, output)
+	}
+	if !reflect.DeepEqual(reparsed.Log, log) {
+		t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", reparsed.Log, log)
+	}
+}
+
+func TestRenderReleaseNotesVersionRange(t *testing.T) {
+	parsed, err := parseMarkdown(strings.NewReader(renderTestChangelog))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	output, err := renderChangelog(parsed.Log, renderOptions{Format: renderFormatReleaseNotes, From: "v1.2.1", To: "v1.2.3"})
+	if err != nil {
+		t.Fatalf("renderChangelog() error = %v", err)
+	}
+
+	want := `## What's Changed since v1.2.1
+
+### Feat
+
+- Add deterministic parser
+
+### Fix
+
+- Keep <this> line
+  - with its detail
+
+### Change
+
+- Update Helm dependency
+`
+	if string(output) != want {
+		t.Fatalf("release notes = %q, want %q", string(output), want)
+	}
+}
+
+func TestRenderHTMLEscapesText(t *testing.T) {
+	log := helmlog.Changelog{Releases: []helmlog.Release{{
+		Version: "v1.0.0",
+		Date:    "2026-01-01",
+		Entries: []helmlog.Entry{{Tag: "Fix", Text: "Escape <script> tags", Origin: helmlog.OriginChart}},
+	}}}
+
+	output, err := renderChangelog(log, renderOptions{Format: renderFormatHTML, Title: "A & B"})
+	if err != nil {
+		t.Fatalf("renderChangelog() error = %v", err)
+	}
+
+	for _, want := range []string{"<title>A &amp; B</title>", "Escape &lt;script&gt; tags"} {
+		if !strings.Contains(string(output), want) {
+			t.Fatalf("html output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRenderCustomTemplate(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "custom.tmpl")
+	content := `{{ range .Releases }}{{ .Version }}:{{ len .Entries }}
+{{ end }}`
+	if err := os.WriteFile(templatePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	parsed, err := parseMarkdown(strings.NewReader(renderTestChangelog))
+	if err != nil {
+		t.Fatalf("parseMarkdown() error = %v", err)
+	}
+
+	output, err := renderChangelog(parsed.Log, renderOptions{TemplatePath: templatePath, To: "v1.2.2"})
+	if err != nil {
+		t.Fatalf("renderChangelog() error = %v", err)
+	}
+
+	if got, want := string(output), "v1.2.2:1\nv1.2.1:1\n"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	_, err := renderChangelog(helmlog.Changelog{}, renderOptions{Format: "pdf"})
+	if err == nil || !strings.Contains(err.Error(), `unknown render format "pdf"`) {
+		t.Fatalf("renderChangelog() error = %v, want unknown format", err)
+	}
+}
+
+func TestMarkdownBulletQualifiers(t *testing.T) {
+	tests := []struct {
+		entry helmlog.Entry
+		want  string
+	}{
+		{entry: helmlog.Entry{Tag: "Feat", Text: "plain"}, want: "- [Feat] plain"},
+		{entry: helmlog.Entry{Tag: "Breaking", Text: "own tag", Breaking: true}, want: "- [Breaking] own tag"},
+		{entry: helmlog.Entry{Tag: "Feat", Text: "breaking", Breaking: true}, want: "- [Breaking][Feat] breaking"},
+		{entry: helmlog.Entry{Tag: "Feat", Text: "warning", Warning: true}, want: "- [:warning: Change][Feat] warning"},
+		{entry: helmlog.Entry{Tag: "Feat", Text: "both", Breaking: true, Warning: true}, want: "- [:warning: Breaking Change][Feat] both"},
+		{entry: helmlog.Entry{Tag: "Breaking", Text: "warned", Breaking: true, Warning: true}, want: "- [:warning: Change][Breaking] warned"},
+	}
+
+	for _, test := range tests {
+		if got := markdownBullet(test.entry); got != test.want {
+			t.Fatalf("markdownBullet(%+v) = %q, want %q", test.entry, got, test.want)
+		}
+
+		parsed, err := parseMarkdown(strings.NewReader("### v1.0.0 / 2026-01-01\n" + test.want + "\n"))
+		if err != nil {
+			t.Fatalf("parseMarkdown(%q) error = %v", test.want, err)
+		}
+		entry := parsed.Log.Releases[0].Entries[0]
+		if entry.Tag != test.entry.Tag || entry.Breaking != test.entry.Breaking || entry.Warning != test.entry.Warning {
+			t.Fatalf("parseMarkdown(%q) = %+v, want %+v", test.want, entry, test.entry)
+		}
+	}
+}
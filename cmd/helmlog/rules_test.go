@@ -0,0 +1,120 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+const rulesTestChangelog = `# Changelog
+
+## Agent
+
+### v1.3.0 / 2026-03-01
+- [Feat] Newest release
+
+### v1.3.0 / 2026-02-20
+- [Fix] Published twice
+
+### v1.2.1 / 2026-03-05
+- [Breaking] Removed a value
+
+### v1.2.0 / 2026-01-01
+- [Feat] Initial release
+
+### v1.4.0 / 2025-12-10
+- [Fix] Out of order
+
+## Collector
+
+### v2.0.0-rc.2+build.7 / 2026-01-02
+- [Feat] Release candidate
+
+### v2.0.0-rc.1 / 2026-01-01
+- [Feat] First candidate
+`
+
+func TestParseMarkdownReleaseSequenceRules(t *testing.T) {
+	_, diagnostics, err := parseMarkdownDiagnostics(strings.NewReader(rulesTestChangelog), parseOptions{Dialect: helmlog.DialectHelm})
+	if err != nil {
+		t.Fatalf("parseMarkdownDiagnostics() error = %v", err)
+	}
+
+	type finding struct {
+		Line int
+		Rule string
+	}
+	got := make([]finding, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		got = append(got, finding{Line: d.LineNumber, Rule: d.Rule})
+	}
+
+	want := []finding{
+		{Line: 8, Rule: helmlog.RuleDuplicateRelease},
+		{Line: 11, Rule: helmlog.RuleReleaseDateOrder},
+		{Line: 11, Rule: helmlog.RuleBreakingChangeBump},
+		{Line: 17, Rule: helmlog.RuleReleaseOrder},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diagnostics = %#v, want %#v", got, want)
+	}
+
+	if got := diagnostics[2].Fix; got != `release as v1.3.0 or later` {
+		t.Fatalf("breaking bump fix = %q", got)
+	}
+}
+
+func TestParseMarkdownBreakingChangeInInitialDevelopment(t *testing.T) {
+	input := `### v0.0.2 / 2026-01-02
+- [:warning: Breaking Change][Fix] Renamed a value
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`
+
+	_, err := parseMarkdown(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "only bumps the patch version of v0.0.1") {
+		t.Fatalf("parseMarkdown() error = %v, want breaking change bump error", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, path, input)
+	writeTestFile(t, filepath.Join(dir, configFileName), "initial_development: true\n")
+	if err := runValidate([]string{path}, validateOptions{}); err != nil {
+		t.Fatalf("runValidate(initial_development) error = %v", err)
+	}
+
+	writeTestFile(t, path, strings.ReplaceAll(input, "v0.0.", "v0.1."))
+	err = runValidate([]string{path}, validateOptions{})
+	if err == nil || !strings.Contains(err.Error(), "only bumps the patch version of v0.1.1") {
+		t.Fatalf("runValidate(initial_development) error = %v, want breaking change bump error after 0.1.z", err)
+	}
+}
+
+func TestReleaseSequenceRulesCanBeDisabled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, path, rulesTestChangelog)
+
+	opts := validateOptions{parseOptions: parseOptions{
+		DisabledRules: []string{helmlog.RuleDuplicateRelease, helmlog.RuleReleaseOrder},
+	}}
+	writeTestFile(t, filepath.Join(dir, configFileName), `
+rules:
+  release-date-order: false
+exceptions:
+  breaking-change-bump: [v1.2.1]
+`)
+	if err := runValidate([]string{path}, opts); err != nil {
+		t.Fatalf("runValidate() error = %v", err)
+	}
+
+	opts.DisabledRules = []string{"no-such-rule"}
+	if err := runValidate([]string{path}, opts); err == nil || !strings.Contains(err.Error(), `unknown rule "no-such-rule"`) {
+		t.Fatalf("runValidate() error = %v, want unknown rule", err)
+	}
+}
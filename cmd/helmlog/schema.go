@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCmd() *cobra.Command {
+	outputPath := ""
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the generate artifact",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runSchema(outputPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (defaults to stdout)")
+
+	return cmd
+}
+
+func runSchema(outputPath string) error {
+	output, err := helmlog.Schema()
+	if err != nil {
+		return fmt.Errorf("ERROR: %w", err)
+	}
+
+	if outputPath == "" || outputPath == "-" {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("ERROR: failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, output, 0o644); err != nil {
+		return fmt.Errorf("ERROR: failed to write %s: %w", outputPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+	"github.com/spf13/cobra"
+)
+
+const upgradingFileName = "UPGRADING.md"
+
+// upgradingSectionPattern matches the "## v0.0.247 to v0.0.248" headings of
+// UPGRADING.md. A section covers the releases after its first version up to
+// and including its second one.
+var upgradingSectionPattern = regexp.MustCompile(`^##\s+(v?\d+\.\d+\.\d+\S*)\s+to\s+(v?\d+\.\d+\.\d+\S*)\s*$`)
+
+type upgradeGuideOptions struct {
+	parseOptions
+	UpgradingPath string
+	Since         string
+	Skeleton      bool
+	Write         bool
+}
+
+// upgradingSection is one version-range heading of UPGRADING.md.
+type upgradingSection struct {
+	Line int
+	From string
+	To   string
+}
+
+func (s upgradingSection) covers(version string) bool {
+	return helmlog.CompareVersions(version, s.From) > 0 && helmlog.CompareVersions(version, s.To) <= 0
+}
+
+// uncoveredRelease is a release with breaking entries that no UPGRADING.md
+// section covers. Previous is the release before it, used as the lower bound of
+// the skeleton heading.
+type uncoveredRelease struct {
+	Version  string
+	Previous string
+	Entries  []helmlog.Entry
+}
+
+func newUpgradeGuideCmd() *cobra.Command {
+	opts := upgradeGuideOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade-guide <CHANGELOG.md>",
+		Short: "Check that breaking changes are covered by UPGRADING.md",
+		Long: `Check that every release with a [Breaking] or :warning: entry is covered by an
+UPGRADING.md section such as "## v0.0.247 to v0.0.248". A section covers the
+releases after its first version up to and including its second one.
+
+UPGRADING.md defaults to the file next to the changelog. --skeleton prints a
+section to fill in for each uncovered release, and --write adds those sections
+to UPGRADING.md in version order.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runUpgradeGuide(args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.UpgradingPath, "upgrading", "", "UPGRADING.md path (defaults to the file next to the changelog)")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Only check releases newer than this version")
+	cmd.Flags().BoolVar(&opts.Skeleton, "skeleton", false, "Print a skeleton section for each uncovered release")
+	cmd.Flags().BoolVar(&opts.Write, "write", false, "Add skeleton sections for uncovered releases to UPGRADING.md")
+	addParseFlags(cmd, &opts.parseOptions)
+
+	return cmd
+}
+
+func runUpgradeGuide(path string, opts upgradeGuideOptions) error {
+	upgradingPath := opts.UpgradingPath
+	if upgradingPath == "" {
+		upgradingPath = filepath.Join(filepath.Dir(path), upgradingFileName)
+	}
+
+	log, err := loadChangelog(path, opts.parseOptions)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(upgradingPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ERROR: failed to read %s: %w", upgradingPath, err)
+	}
+
+	uncovered, err := findUncoveredReleases(log.Releases, parseUpgradingSections(string(content)), opts.Since)
+	if err != nil {
+		return err
+	}
+
+	if len(uncovered) == 0 {
+		fmt.Printf("OK: %s covers every breaking change in %s\n", upgradingPath, path)
+		return nil
+	}
+
+	if opts.Write {
+		updated := insertUpgradingSections(string(content), uncovered)
+		if err := os.WriteFile(upgradingPath, []byte(updated), 0o644); err != nil {
+			return fmt.Errorf("ERROR: failed to write %s: %w", upgradingPath, err)
+		}
+		fmt.Printf("Added %d sections to %s\n", len(uncovered), upgradingPath)
+		return nil
+	}
+
+	if opts.Skeleton {
+		for _, release := range uncovered {
+			fmt.Print(renderUpgradingSection(release) + "\n")
+		}
+	}
+
+	problems := make([]string, 0, len(uncovered))
+	for _, release := range uncovered {
+		for _, entry := range release.Entries {
+			problems = append(problems, fmt.Sprintf("ERROR: no section in %s covers %s: [%s] %s", upgradingPath, release.Version, upgradeGuideLabel(entry), entryLines(entry)[0]))
+		}
+	}
+
+	return errors.New(strings.Join(problems, "\n"))
+}
+
+// upgradeGuideLabel names the tag an entry was written with, so a
+// [:warning: Change][Feat] entry is not reported as a plain feature.
+func upgradeGuideLabel(entry helmlog.Entry) string {
+	if entry.Warning && entry.Tag != "Breaking" {
+		return ":warning: " + entry.Tag
+	}
+
+	return entry.Tag
+}
+
+func parseUpgradingSections(content string) []upgradingSection {
+	sections := []upgradingSection{}
+	for i, line := range strings.Split(content, "\n") {
+		match := upgradingSectionPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		sections = append(sections, upgradingSection{Line: i + 1, From: match[1], To: match[2]})
+	}
+
+	return sections
+}
+
+// findUncoveredReleases returns the releases newer than since whose breaking
+// entries no section covers, newest first. Entries inherited from upstream
+// changelogs are left to the upstream guide.
+func findUncoveredReleases(releases []helmlog.Release, sections []upgradingSection, since string) ([]uncoveredRelease, error) {
+	inRange, err := releasesInRange(releases, since, "")
+	if err != nil {
+		return nil, err
+	}
+
+	uncovered := []uncoveredRelease{}
+	for _, release := range inRange {
+		if isCovered(release.Version, sections) {
+			continue
+		}
+
+		entries := []helmlog.Entry{}
+		for _, entry := range localEntries(release) {
+			if isHighlighted(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		uncovered = append(uncovered, uncoveredRelease{
+			Version:  release.Version,
+			Previous: previousRelease(releases, release.Version),
+			Entries:  entries,
+		})
+	}
+
+	return uncovered, nil
+}
+
+func isCovered(version string, sections []upgradingSection) bool {
+	for _, section := range sections {
+		if section.covers(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// previousRelease returns the newest release older than version, or "" for the
+// first release.
+func previousRelease(releases []helmlog.Release, version string) string {
+	previous := ""
+	for _, release := range releases {
+		if helmlog.CompareVersions(release.Version, version) >= 0 {
+			continue
+		}
+		if previous == "" || helmlog.CompareVersions(release.Version, previous) > 0 {
+			previous = release.Version
+		}
+	}
+
+	return previous
+}
+
+func renderUpgradingSection(release uncoveredRelease) string {
+	var b strings.Builder
+
+	// The first release has nothing to upgrade from; 0.0.0 still makes the
+	// heading cover it.
+	previous := release.Previous
+	if previous == "" {
+		previous = strings.TrimSuffix(release.Version, trimVersionPrefix(release.Version)) + "0.0.0"
+	}
+	fmt.Fprintf(&b, "## %s to %s\n\n", previous, release.Version)
+	b.WriteString("<!-- TODO: describe the manual steps needed for these changes. -->\n\n")
+	for _, entry := range release.Entries {
+		lines := entryLines(entry)
+		b.WriteString("- " + lines[0] + "\n")
+		for _, sub := range lines[1:] {
+			b.WriteString("  - " + sub + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// insertUpgradingSections adds a skeleton section for each release before the
+// first existing section for an older version, keeping UPGRADING.md newest
+// first. Sections older than every existing one go at the end.
+func insertUpgradingSections(content string, releases []uncoveredRelease) string {
+	if strings.TrimSpace(content) == "" {
+		content = "# Upgrade guidelines\n"
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for _, release := range releases {
+		section := strings.Split(strings.TrimRight(renderUpgradingSection(release), "\n"), "\n")
+
+		at := len(lines)
+		for _, existing := range parseUpgradingSections(strings.Join(lines, "\n")) {
+			if helmlog.CompareVersions(existing.To, release.Version) < 0 {
+				at = existing.Line - 1
+				break
+			}
+		}
+
+		block := append([]string{}, section...)
+		if at == len(lines) {
+			block = append([]string{""}, block...)
+		} else {
+			block = append(block, "")
+		}
+
+		lines = append(lines[:at], append(block, lines[at:]...)...)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
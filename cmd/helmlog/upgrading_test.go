@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coralogix/telemetry-shippers/pkg/helmlog"
+)
+
+const upgradingTestChangelog = `# Changelog
+
+### v0.0.5 / 2026-01-05
+- [:warning: Change][Feat] Bump collector version to 0.110.0
+
+### v0.0.4 / 2026-01-04
+- [Fix] Fix exporter retries
+
+### v0.0.3 / 2026-01-03
+- [Breaking] Drop the legacy logs pipeline
+- [Feat] Add host metrics preset
+
+### v0.0.2 / 2026-01-02
+- [Breaking] Rename the metrics preset
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`
+
+const upgradingTestGuide = `# Upgrade guidelines
+
+## v0.0.3 to v0.0.4
+
+Nothing breaking here, but the section is kept.
+
+## v0.0.1 to v0.0.2
+
+Rename ` + "`metrics`" + ` to ` + "`hostMetrics`" + `.
+`
+
+func TestFindUncoveredReleases(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	writeTestFile(t, path, upgradingTestChangelog)
+	writeTestFile(t, filepath.Join(dir, configFileName), initialDevelopmentConfig)
+
+	log, err := loadChangelog(path, parseOptions{})
+	if err != nil {
+		t.Fatalf("loadChangelog() error = %v", err)
+	}
+
+	uncovered, err := findUncoveredReleases(log.Releases, parseUpgradingSections(upgradingTestGuide), "")
+	if err != nil {
+		t.Fatalf("findUncoveredReleases() error = %v", err)
+	}
+
+	got := []string{}
+	for _, release := range uncovered {
+		for _, entry := range release.Entries {
+			got = append(got, release.Previous+".."+release.Version+" ["+upgradeGuideLabel(entry)+"] "+entry.Text)
+		}
+	}
+	want := []string{
+		"v0.0.4..v0.0.5 [:warning: Feat] Bump collector version to 0.110.0",
+		"v0.0.2..v0.0.3 [Breaking] Drop the legacy logs pipeline",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("uncovered = %#v, want %#v", got, want)
+	}
+
+	uncovered, err = findUncoveredReleases(log.Releases, parseUpgradingSections(upgradingTestGuide), "v0.0.3")
+	if err != nil {
+		t.Fatalf("findUncoveredReleases(since) error = %v", err)
+	}
+	if len(uncovered) != 1 || uncovered[0].Version != "v0.0.5" {
+		t.Fatalf("uncovered since v0.0.3 = %#v, want v0.0.5 only", uncovered)
+	}
+}
+
+func TestRunUpgradeGuideWritesSkeletonSections(t *testing.T) {
+	dir := t.TempDir()
+	changelogPath := filepath.Join(dir, "CHANGELOG.md")
+	upgradingPath := filepath.Join(dir, upgradingFileName)
+	writeTestFile(t, changelogPath, upgradingTestChangelog)
+	writeTestFile(t, upgradingPath, upgradingTestGuide)
+	writeTestFile(t, filepath.Join(dir, configFileName), initialDevelopmentConfig)
+
+	err := runUpgradeGuide(changelogPath, upgradeGuideOptions{})
+	if err == nil || !strings.Contains(err.Error(), "covers v0.0.3: [Breaking] Drop the legacy logs pipeline") {
+		t.Fatalf("runUpgradeGuide() error = %v, want uncovered v0.0.3", err)
+	}
+
+	if err := runUpgradeGuide(changelogPath, upgradeGuideOptions{Write: true}); err != nil {
+		t.Fatalf("runUpgradeGuide(--write) error = %v", err)
+	}
+
+	content, err := os.ReadFile(upgradingPath)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	want := `# Upgrade guidelines
+
+## v0.0.4 to v0.0.5
+
+<!-- TODO: describe the manual steps needed for these changes. -->
+
+- Bump collector version to 0.110.0
+
+## v0.0.3 to v0.0.4
+
+Nothing breaking here, but the section is kept.
+
+## v0.0.2 to v0.0.3
+
+<!-- TODO: describe the manual steps needed for these changes. -->
+
+- Drop the legacy logs pipeline
+
+## v0.0.1 to v0.0.2
+
+Rename ` + "`metrics`" + ` to ` + "`hostMetrics`" + `.
+`
+	if string(content) != want {
+		t.Fatalf("UPGRADING.md = %q, want %q", content, want)
+	}
+
+	if err := runUpgradeGuide(changelogPath, upgradeGuideOptions{}); err != nil {
+		t.Fatalf("runUpgradeGuide() after --write error = %v", err)
+	}
+}
+
+func TestInsertUpgradingSectionsCreatesGuide(t *testing.T) {
+	got := insertUpgradingSections("", []uncoveredRelease{{
+		Version: "v0.0.1",
+		Entries: []helmlog.Entry{{Tag: "Breaking", Text: "First release"}},
+	}})
+
+	want := `# Upgrade guidelines
+
+## v0.0.0 to v0.0.1
+
+<!-- TODO: describe the manual steps needed for these changes. -->
+
+- First release
+`
+	if got != want {
+		t.Fatalf("insertUpgradingSections() = %q, want %q", got, want)
+	}
+	if !isCovered("v0.0.1", parseUpgradingSections(got)) {
+		t.Fatalf("generated section does not cover v0.0.1")
+	}
+}
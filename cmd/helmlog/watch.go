@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce collects the events of one save. Editors often write a file
+// in several steps, or write a temporary file and rename it over the original.
+var watchDebounce = 100 * time.Millisecond
+
+// runValidateWatch validates the changelogs once and again after every save
+// until ctx is done. Problems are printed instead of ending the watch.
+func runValidateWatch(ctx context.Context, paths []string, opts validateOptions) error {
+	report := func(changed []string) {
+		if err := runValidate(changed, opts); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+	}
+
+	report(paths)
+	fmt.Printf("Watching %d changelog file(s) for changes. Press Ctrl+C to stop.\n", len(paths))
+
+	return watchFiles(ctx, paths, func(changed []string) {
+		fmt.Printf("\n[%s] Changed: %v\n", nowFunc().Format(time.TimeOnly), changed)
+		report(changed)
+	})
+}
+
+// watchFiles calls onChange with the paths written since the last call, in
+// the order given. A change to a .helmlog.yaml next to any of them counts as
+// a change to all paths. The parent directories are watched rather than the
+// files, so a file replaced by a rename is still followed.
+func watchFiles(ctx context.Context, paths []string, onChange func([]string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("ERROR: failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	targets := map[string]string{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("ERROR: failed to resolve %s: %w", path, err)
+		}
+		targets[abs] = path
+	}
+
+	watched := map[string]bool{}
+	for abs := range targets {
+		dir := filepath.Dir(abs)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("ERROR: failed to watch %s: %w", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			name := filepath.Clean(event.Name)
+			switch path, ok := targets[name]; {
+			case ok:
+				pending[path] = true
+			case filepath.Base(name) == configFileName:
+				for _, path := range paths {
+					pending[path] = true
+				}
+			default:
+				continue
+			}
+			timer.Reset(watchDebounce)
+
+		case <-timer.C:
+			changed := []string{}
+			for _, path := range paths {
+				if pending[path] {
+					changed = append(changed, path)
+					delete(pending, path)
+				}
+			}
+			if len(changed) > 0 {
+				onChange(changed)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("ERROR: file watcher failed: %w", err)
+		}
+	}
+}
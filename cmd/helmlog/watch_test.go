@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchFilesReportsSavedChangelogs(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first", changelogFileName)
+	second := filepath.Join(dir, "second", changelogFileName)
+	writeTestFile(t, first, "# Changelog\n")
+	writeTestFile(t, second, "# Changelog\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan []string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchFiles(ctx, []string{first, second}, func(changed []string) {
+			changes <- changed
+		})
+	}()
+
+	// The watcher starts asynchronously, so keep saving until it reports.
+	// Saves are spaced out beyond the debounce delay.
+	waitForChange := func(save func()) []string {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		tick := time.NewTicker(3 * watchDebounce)
+		defer tick.Stop()
+		for {
+			select {
+			case changed := <-changes:
+				return changed
+			case <-tick.C:
+				save()
+			case <-deadline:
+				t.Fatal("watchFiles() reported no change")
+			}
+		}
+	}
+
+	if got := waitForChange(func() { writeTestFile(t, second, "# Changelog\n\n") }); !reflect.DeepEqual(got, []string{second}) {
+		t.Fatalf("changed = %#v, want %#v", got, []string{second})
+	}
+
+	// A configuration change revalidates every changelog.
+	if got := waitForChange(func() { writeTestFile(t, filepath.Join(dir, "first", configFileName), "rules: {}\n") }); !reflect.DeepEqual(got, []string{first, second}) {
+		t.Fatalf("changed = %#v, want both changelogs", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchFiles() error = %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Default number of sub-batches of one chunk sent at the same time
+const defaultMaxConcurrency = 4
+
+// Number of partly sent chunks whose sent sub-batches are remembered
+const maxSentChunks = 256
+
+// splitBatch cuts records into sub-batches of at most maxRecords records and
+// about maxBytes bytes of uncompressed payload. A zero limit is no limit. A
+// record larger than maxBytes on its own is sent in a sub-batch of its own.
+// No records make no sub-batches.
+func splitBatch(records []logRecord, protocol string, maxRecords int, maxBytes int64) [][]logRecord {
+	if len(records) == 0 {
+		return nil
+	}
+	if maxRecords <= 0 && maxBytes <= 0 {
+		return [][]logRecord{records}
+	}
+
+	var batches [][]logRecord
+	var current []logRecord
+	var currentBytes int64
+	resources := make(map[otlpResource]bool)
+	for _, record := range records {
+		var recordBytes int64
+		if maxBytes > 0 {
+			recordBytes = recordSize(protocol, record, resources)
+		}
+
+		full := maxRecords > 0 && len(current) >= maxRecords
+		if maxBytes > 0 && currentBytes+recordBytes > maxBytes {
+			full = true
+		}
+		if full && len(current) > 0 {
+			batches = append(batches, current)
+			current = nil
+			currentBytes = 0
+			resources = make(map[otlpResource]bool)
+			if maxBytes > 0 {
+				recordBytes = recordSize(protocol, record, resources)
+			}
+		}
+
+		current = append(current, record)
+		currentBytes += recordBytes
+		resources[record.resource()] = true
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// recordSize estimates the bytes record adds to an encoded sub-batch. OTLP
+// records only add their resource attributes for the first record of each
+// resource in the sub-batch.
+func recordSize(protocol string, record logRecord, resources map[otlpResource]bool) int64 {
+	if protocol == protocolOTLP && resources[record.resource()] {
+		return int64(len(appendBytesField(nil, otlpScopeLogsLogRecords, encodeOTLPLogRecord(record, record.timestamp))))
+	}
+
+	return int64(len(encodeBatch(protocol, []logRecord{record})))
+}
+
+// encodeBatch encodes records as the request body of protocol
+func encodeBatch(protocol string, records []logRecord) []byte {
+	if protocol == protocolOTLP {
+		return encodeOTLPLogs(records, time.Now())
+	}
+
+	payload, _ := jsoniter.Marshal(restBatch(records))
+	return payload
+}
+
+// compressBatch gzips an encoded batch
+func compressBatch(payload []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	zipper, err := gzip.NewWriterLevel(&buffer, 9)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zipper.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := zipper.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// sendBatches posts the compressed sub-batches with at most maxConcurrency
+// requests in flight and returns the error of each, nil when it was sent.
+// Retries of all the sub-batches stop after retryFlushBudget.
+func sendBatches(inst *instance, payloads [][]byte) []error {
+	concurrency := inst.maxConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	deadline := time.Now().Add(retryFlushBudget)
+
+	errs := make([]error, len(payloads))
+	slots := make(chan struct{}, concurrency)
+	var wait sync.WaitGroup
+	for i, payload := range payloads {
+		wait.Add(1)
+		slots <- struct{}{}
+		go func(i int, payload []byte) {
+			defer wait.Done()
+			defer func() { <-slots }()
+			errs[i] = inst.sendWithRetry(inst.config["protocol"], payload, deadline)
+		}(i, payload)
+	}
+	wait.Wait()
+
+	return errs
+}
+
+// sentBatches remembers which sub-batches of a chunk were sent or dropped
+// when others failed, so the retry of the chunk only sends the failed ones.
+// Chunks are identified by the hash of their content. Fluent-Bit does not
+// report the chunks it gives up on, so the oldest ones are forgotten once
+// maxSentChunks are remembered.
+type sentBatches struct {
+	mutex  sync.Mutex
+	chunks map[[sha256.Size]byte][]bool
+	order  [][sha256.Size]byte
+}
+
+// take returns and forgets the sub-batches of chunk that were sent, nil when
+// the chunk is unknown or was split into a different number of sub-batches
+func (s *sentBatches) take(chunk [sha256.Size]byte, count int) []bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sent, ok := s.chunks[chunk]
+	if !ok {
+		return nil
+	}
+	delete(s.chunks, chunk)
+	for i := range s.order {
+		if s.order[i] == chunk {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	if len(sent) != count {
+		return nil
+	}
+	return sent
+}
+
+// remember records the sub-batches of chunk that were sent
+func (s *sentBatches) remember(chunk [sha256.Size]byte, sent []bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.chunks == nil {
+		s.chunks = make(map[[sha256.Size]byte][]bool)
+	}
+	if _, ok := s.chunks[chunk]; !ok {
+		s.order = append(s.order, chunk)
+	}
+	s.chunks[chunk] = sent
+	for len(s.order) > maxSentChunks {
+		delete(s.chunks, s.order[0])
+		s.order = s.order[1:]
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-bit-go/output"
+)
+
+func TestSplitBatch(t *testing.T) {
+	records := make([]logRecord, 5)
+	for i := range records {
+		records[i] = logRecord{applicationName: "app", subsystemName: "sub", computerName: "host", timestamp: time.Unix(1700000000, 0), text: strings.Repeat("x", 100)}
+	}
+	twoRecords := int64(len(encodeBatch(protocolOTLP, records[:2])))
+
+	tests := []struct {
+		name       string
+		records    []logRecord
+		protocol   string
+		maxRecords int
+		maxBytes   int64
+		want       []int
+	}{
+		{name: "empty input", protocol: protocolREST, want: nil},
+		{name: "empty input with limits", protocol: protocolOTLP, maxRecords: 2, maxBytes: 1024, want: nil},
+		{name: "no limits", records: records, protocol: protocolREST, want: []int{5}},
+		{name: "record limit", records: records, protocol: protocolREST, maxRecords: 2, want: []int{2, 2, 1}},
+		{name: "record limit above the batch", records: records, protocol: protocolREST, maxRecords: 10, want: []int{5}},
+		{name: "byte limit", records: records, protocol: protocolOTLP, maxBytes: twoRecords, want: []int{2, 2, 1}},
+		{name: "byte and record limits", records: records, protocol: protocolOTLP, maxRecords: 1, maxBytes: twoRecords, want: []int{1, 1, 1, 1, 1}},
+		{name: "oversize records", records: records[:3], protocol: protocolOTLP, maxBytes: 10, want: []int{1, 1, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			batches := splitBatch(test.records, test.protocol, test.maxRecords, test.maxBytes)
+
+			var sizes []int
+			var joined []logRecord
+			for _, batch := range batches {
+				sizes = append(sizes, len(batch))
+				joined = append(joined, batch...)
+			}
+			if !reflect.DeepEqual(sizes, test.want) {
+				t.Fatalf("splitBatch() sizes = %v, want %v", sizes, test.want)
+			}
+			if !reflect.DeepEqual(joined, test.records) {
+				t.Fatalf("splitBatch() lost or reordered records")
+			}
+
+			// Only a record that is too large on its own may exceed the limit
+			for i, batch := range batches {
+				if test.maxBytes == 0 || len(batch) == 1 {
+					continue
+				}
+				if size := int64(len(encodeBatch(test.protocol, batch))); size > test.maxBytes {
+					t.Fatalf("batch %d is %d bytes, want at most %d", i, size, test.maxBytes)
+				}
+			}
+		})
+	}
+}
+
+func TestSentBatches(t *testing.T) {
+	var sent sentBatches
+	first := sha256.Sum256([]byte("first chunk"))
+	second := sha256.Sum256([]byte("second chunk"))
+
+	if got := sent.take(first, 2); got != nil {
+		t.Fatalf("take() of an unknown chunk = %v, want nil", got)
+	}
+
+	sent.remember(first, []bool{true, false})
+	sent.remember(second, []bool{false, true, true})
+	if got := sent.take(first, 2); !reflect.DeepEqual(got, []bool{true, false}) {
+		t.Fatalf("take() = %v, want [true false]", got)
+	}
+	if got := sent.take(first, 2); got != nil {
+		t.Fatalf("take() after take() = %v, want nil", got)
+	}
+
+	// A chunk split differently is sent again in full
+	if got := sent.take(second, 2); got != nil {
+		t.Fatalf("take() with another sub-batch count = %v, want nil", got)
+	}
+
+	// The oldest chunks are forgotten first
+	for i := 0; i <= maxSentChunks; i++ {
+		sent.remember(sha256.Sum256([]byte(fmt.Sprint(i))), []bool{true})
+	}
+	if got := sent.take(sha256.Sum256([]byte("0")), 1); got != nil {
+		t.Fatalf("take() of a forgotten chunk = %v, want nil", got)
+	}
+	if got := sent.take(sha256.Sum256([]byte(fmt.Sprint(maxSentChunks))), 1); got == nil {
+		t.Fatal("take() of the newest chunk = nil, want it remembered")
+	}
+}
+
+func TestFlushBatchesResendsOnlyFailedSubBatches(t *testing.T) {
+	var mutex sync.Mutex
+	var received []string
+	failing := "second"
+	inst := newServerInstance(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, string(body))
+		if string(body) == failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	inst.maxRetries = 0
+
+	chunk := sha256.Sum256([]byte("chunk"))
+	names := []string{"first", "second", "third"}
+	var batches [][]logRecord
+	var payloads [][]byte
+	for _, name := range names {
+		batches = append(batches, []logRecord{{text: name}})
+		payloads = append(payloads, []byte(name))
+	}
+
+	if got := inst.flushBatches(chunk, batches, payloads); got != output.FLB_RETRY {
+		t.Fatalf("flushBatches() = %d, want FLB_RETRY", got)
+	}
+	if !reflect.DeepEqual(received, names) {
+		t.Fatalf("first attempt sent %v, want %v", received, names)
+	}
+
+	// Fluent-Bit retries the same chunk, only the failed sub-batch is sent
+	received = nil
+	failing = ""
+	if got := inst.flushBatches(chunk, batches, payloads); got != output.FLB_OK {
+		t.Fatalf("flushBatches() on retry = %d, want FLB_OK", got)
+	}
+	if want := []string{"second"}; !reflect.DeepEqual(received, want) {
+		t.Fatalf("retry sent %v, want %v", received, want)
+	}
+
+	// A chunk that succeeded is forgotten and sent in full again
+	received = nil
+	if got := inst.flushBatches(chunk, batches, payloads); got != output.FLB_OK {
+		t.Fatalf("flushBatches() after success = %d, want FLB_OK", got)
+	}
+	if !reflect.DeepEqual(received, names) {
+		t.Fatalf("sent %v after success, want %v", received, names)
+	}
+}
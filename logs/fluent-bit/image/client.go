@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default request timeout
+const defaultTimeout = 30 * time.Second
+
+// proxyFromEnvironment picks the proxy of the environment variables,
+// replaced in tests
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+// clientOptions are the TLS, proxy and timeout options of an output
+type clientOptions struct {
+	caFile         string
+	verify         bool
+	certFile       string
+	keyFile        string
+	proxy          string
+	noProxy        string
+	timeout        time.Duration
+	maxConcurrency int
+}
+
+// newHTTPClient builds the client an output keeps for all its requests, so
+// connections are reused across flushes
+func newHTTPClient(options clientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: !options.verify}
+
+	// Trust the CA of a TLS inspecting proxy on top of the system ones
+	if options.caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(options.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read TLS_CA_File: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificate found in TLS_CA_File %s", options.caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// Present a client certificate
+	if options.certFile != "" || options.keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.certFile, options.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS_Cert_File and TLS_Key_File: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if options.maxConcurrency > transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConnsPerHost = options.maxConcurrency
+	}
+
+	// Without HTTP_Proxy the HTTPS_PROXY and NO_PROXY variables apply. The
+	// NO_PROXY option bypasses either proxy.
+	proxy := proxyFromEnvironment
+	if options.proxy != "" {
+		proxyURL, err := url.Parse(options.proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid HTTP_Proxy %q", options.proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	bypass := splitNoProxy(options.noProxy)
+	transport.Proxy = func(request *http.Request) (*url.URL, error) {
+		if bypassProxy(request.URL, bypass) {
+			return nil, nil
+		}
+		return proxy(request)
+	}
+
+	return &http.Client{Transport: transport, Timeout: options.timeout}, nil
+}
+
+// splitNoProxy reads a comma separated NO_PROXY list
+func splitNoProxy(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// bypassProxy reports whether target matches a NO_PROXY entry: "*", an IP
+// address or CIDR range, or a domain, which also matches its subdomains.
+// Host and IP entries with a port, such as "gateway.internal:4318", only
+// match that port.
+func bypassProxy(target *url.URL, entries []string) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" && target.Scheme == "https" {
+		port = "443"
+	} else if port == "" {
+		port = "80"
+	}
+	ip := net.ParseIP(host)
+	for _, entry := range entries {
+		if entry == "*" {
+			return true
+		}
+		if entryHost, entryPort, err := net.SplitHostPort(entry); err == nil {
+			if entryPort != port {
+				continue
+			}
+			entry = entryHost
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
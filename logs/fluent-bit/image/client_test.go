@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSplitNoProxy(t *testing.T) {
+	got := splitNoProxy(" Internal.Example.com, ,10.0.0.0/8,,localhost ")
+	want := []string{"internal.example.com", "10.0.0.0/8", "localhost"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitNoProxy() = %#v, want %#v", got, want)
+	}
+
+	if got := splitNoProxy(""); got != nil {
+		t.Fatalf("splitNoProxy(\"\") = %#v, want nil", got)
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		noProxy string
+		want    bool
+	}{
+		{name: "empty list", target: "https://ingress.coralogix.com/v1/logs", noProxy: "", want: false},
+		{name: "wildcard", target: "https://ingress.coralogix.com/v1/logs", noProxy: "*", want: true},
+		{name: "exact domain", target: "https://ingress.coralogix.com/v1/logs", noProxy: "ingress.coralogix.com", want: true},
+		{name: "parent domain", target: "https://ingress.coralogix.com/v1/logs", noProxy: "coralogix.com", want: true},
+		{name: "leading dot", target: "https://ingress.coralogix.com/v1/logs", noProxy: ".coralogix.com", want: true},
+		{name: "domain suffix is not a subdomain", target: "https://ingress.notcoralogix.com/v1/logs", noProxy: "coralogix.com", want: false},
+		{name: "case insensitive", target: "https://Ingress.Coralogix.com/v1/logs", noProxy: "coralogix.com", want: true},
+		{name: "host with port", target: "https://gateway.internal:4318/v1/logs", noProxy: "gateway.internal", want: true},
+		{name: "ip address", target: "http://10.1.2.3:8080/logs", noProxy: "10.1.2.3", want: true},
+		{name: "other ip address", target: "http://10.1.2.4:8080/logs", noProxy: "10.1.2.3", want: false},
+		{name: "cidr range", target: "http://10.1.2.3/logs", noProxy: "192.168.0.0/16,10.0.0.0/8", want: true},
+		{name: "outside cidr range", target: "http://172.16.0.1/logs", noProxy: "10.0.0.0/8", want: false},
+		{name: "cidr range does not match names", target: "https://ingress.coralogix.com/v1/logs", noProxy: "10.0.0.0/8", want: false},
+		{name: "ipv6 address", target: "http://[::1]:8080/logs", noProxy: "::1", want: true},
+		{name: "host and port", target: "https://gateway.internal:4318/v1/logs", noProxy: "gateway.internal:4318", want: true},
+		{name: "host and other port", target: "https://gateway.internal:4318/v1/logs", noProxy: "gateway.internal:4317", want: false},
+		{name: "default https port", target: "https://ingress.coralogix.com/v1/logs", noProxy: "ingress.coralogix.com:443", want: true},
+		{name: "default http port", target: "http://ingress.coralogix.com/logs", noProxy: "ingress.coralogix.com:443", want: false},
+		{name: "domain and port", target: "https://ingress.coralogix.com/v1/logs", noProxy: "coralogix.com:443", want: true},
+		{name: "ip address and port", target: "http://10.1.2.3:8080/logs", noProxy: "10.1.2.3:8080", want: true},
+		{name: "ipv6 address and port", target: "http://[::1]:8080/logs", noProxy: "[::1]:8080", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, err := url.Parse(test.target)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+			if got := bypassProxy(target, splitNoProxy(test.noProxy)); got != test.want {
+				t.Fatalf("bypassProxy(%s, %q) = %t, want %t", test.target, test.noProxy, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	original := proxyFromEnvironment
+	t.Cleanup(func() { proxyFromEnvironment = original })
+	environmentProxy, _ := url.Parse("http://env-proxy.internal:8080")
+	proxyFromEnvironment = http.ProxyURL(environmentProxy)
+
+	tests := []struct {
+		name    string
+		options clientOptions
+		target  string
+		want    string
+	}{
+		{name: "HTTP_Proxy", options: clientOptions{proxy: "http://proxy.internal:3128", noProxy: "coralogix.us"}, target: "https://ingress.coralogix.com/v1/logs", want: "http://proxy.internal:3128"},
+		{name: "HTTP_Proxy bypassed", options: clientOptions{proxy: "http://proxy.internal:3128", noProxy: "coralogix.us"}, target: "https://ingress.coralogix.us/v1/logs", want: ""},
+		{name: "environment proxy", options: clientOptions{noProxy: "coralogix.us"}, target: "https://ingress.coralogix.com/v1/logs", want: "http://env-proxy.internal:8080"},
+		{name: "environment proxy bypassed", options: clientOptions{noProxy: "coralogix.us"}, target: "https://ingress.coralogix.us/v1/logs", want: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.verify = true
+			client, err := newHTTPClient(test.options)
+			if err != nil {
+				t.Fatalf("newHTTPClient() error = %v", err)
+			}
+			request, err := http.NewRequest(http.MethodPost, test.target, nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() error = %v", err)
+			}
+			got, err := client.Transport.(*http.Transport).Proxy(request)
+			if err != nil {
+				t.Fatalf("Proxy(%s) error = %v", test.target, err)
+			}
+			if (got == nil && test.want != "") || (got != nil && got.String() != test.want) {
+				t.Fatalf("Proxy(%s) = %v, want %q", test.target, got, test.want)
+			}
+		})
+	}
+
+	if _, err := newHTTPClient(clientOptions{proxy: "not a url"}); err == nil {
+		t.Fatal("newHTTPClient() with an invalid HTTP_Proxy succeeded")
+	}
+}
@@ -7,4 +7,6 @@ require (
 	github.com/fluent/fluent-bit-go v0.0.0-20201210173045-3fd1e0486df2
 	github.com/json-iterator/go v1.1.10
 	github.com/thedevsaddam/gojsonq v2.3.0+incompatible
+	go.opentelemetry.io/proto/otlp v0.19.0
+	google.golang.org/protobuf v1.27.1
 )
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// OTLP field numbers, from opentelemetry/proto/collector/logs/v1/logs_service.proto,
+// opentelemetry/proto/logs/v1/logs.proto and opentelemetry/proto/common/v1/common.proto.
+// The messages are encoded by hand to keep the protobuf runtime out of the plugin.
+const (
+	otlpExportRequestResourceLogs     = 1
+	otlpResourceLogsResource          = 1
+	otlpResourceLogsScopeLogs         = 2
+	otlpResourceAttributes            = 1
+	otlpScopeLogsLogRecords           = 2
+	otlpLogRecordTimeUnixNano         = 1
+	otlpLogRecordBody                 = 5
+	otlpLogRecordObservedTimeUnixNano = 11
+	otlpKeyValueKey                   = 1
+	otlpKeyValueValue                 = 2
+	otlpAnyValueStringValue           = 1
+)
+
+// Protobuf wire types
+const (
+	wireFixed64         = 1
+	wireLengthDelimited = 2
+)
+
+// otlpResource identifies the records sharing one ResourceLogs
+type otlpResource struct {
+	applicationName string
+	subsystemName   string
+	computerName    string
+}
+
+// resource returns the OTLP resource of record
+func (record logRecord) resource() otlpResource {
+	return otlpResource{record.applicationName, record.subsystemName, record.computerName}
+}
+
+// encodeOTLPLogs builds an ExportLogsServiceRequest with one ResourceLogs
+// per application, subsystem and host, in the order they first appear
+func encodeOTLPLogs(records []logRecord, observed time.Time) []byte {
+	var resources []otlpResource
+	grouped := make(map[otlpResource][]byte)
+	for _, record := range records {
+		resource := record.resource()
+		if _, exists := grouped[resource]; !exists {
+			resources = append(resources, resource)
+		}
+		grouped[resource] = appendBytesField(grouped[resource], otlpScopeLogsLogRecords, encodeOTLPLogRecord(record, observed))
+	}
+
+	var request []byte
+	for _, resource := range resources {
+		var attributes []byte
+		attributes = appendBytesField(attributes, otlpResourceAttributes, encodeOTLPStringAttribute("cx.application.name", resource.applicationName))
+		attributes = appendBytesField(attributes, otlpResourceAttributes, encodeOTLPStringAttribute("cx.subsystem.name", resource.subsystemName))
+		attributes = appendBytesField(attributes, otlpResourceAttributes, encodeOTLPStringAttribute("host.name", resource.computerName))
+
+		var resourceLogs []byte
+		resourceLogs = appendBytesField(resourceLogs, otlpResourceLogsResource, attributes)
+		resourceLogs = appendBytesField(resourceLogs, otlpResourceLogsScopeLogs, grouped[resource])
+
+		request = appendBytesField(request, otlpExportRequestResourceLogs, resourceLogs)
+	}
+
+	return request
+}
+
+// encodeOTLPLogRecord encodes a LogRecord with the record text as string body
+func encodeOTLPLogRecord(record logRecord, observed time.Time) []byte {
+	var body []byte
+	body = appendBytesField(body, otlpAnyValueStringValue, []byte(record.text))
+
+	var message []byte
+	message = appendFixed64Field(message, otlpLogRecordTimeUnixNano, uint64(record.timestamp.UnixNano()))
+	message = appendBytesField(message, otlpLogRecordBody, body)
+	message = appendFixed64Field(message, otlpLogRecordObservedTimeUnixNano, uint64(observed.UnixNano()))
+
+	return message
+}
+
+// encodeOTLPStringAttribute encodes a KeyValue holding a string
+func encodeOTLPStringAttribute(key string, value string) []byte {
+	var anyValue []byte
+	anyValue = appendBytesField(anyValue, otlpAnyValueStringValue, []byte(value))
+
+	var keyValue []byte
+	keyValue = appendBytesField(keyValue, otlpKeyValueKey, []byte(key))
+	keyValue = appendBytesField(keyValue, otlpKeyValueValue, anyValue)
+
+	return keyValue
+}
+
+func appendVarint(buffer []byte, value uint64) []byte {
+	var encoded [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(encoded[:], value)
+	return append(buffer, encoded[:n]...)
+}
+
+func appendTag(buffer []byte, field int, wireType int) []byte {
+	return appendVarint(buffer, uint64(field)<<3|uint64(wireType))
+}
+
+func appendBytesField(buffer []byte, field int, value []byte) []byte {
+	buffer = appendTag(buffer, field, wireLengthDelimited)
+	buffer = appendVarint(buffer, uint64(len(value)))
+	return append(buffer, value...)
+}
+
+func appendFixed64Field(buffer []byte, field int, value uint64) []byte {
+	var encoded [8]byte
+	binary.LittleEndian.PutUint64(encoded[:], value)
+	buffer = appendTag(buffer, field, wireFixed64)
+	return append(buffer, encoded[:]...)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncodeOTLPLogs(t *testing.T) {
+	observed := time.Unix(1700000100, 0)
+	first := time.Unix(1700000000, 123)
+	second := time.Unix(1700000001, 0)
+
+	tests := []struct {
+		name    string
+		records []logRecord
+		// want lists, per ResourceLogs, its attributes and the bodies of its
+		// records
+		want []wantResourceLogs
+	}{
+		{
+			name: "empty batch",
+		},
+		{
+			name: "records grouped by resource in order of appearance",
+			records: []logRecord{
+				{applicationName: "app", subsystemName: "api", computerName: "node-1", timestamp: first, text: "first"},
+				{applicationName: "other", subsystemName: "api", computerName: "node-1", timestamp: second, text: "second"},
+				{applicationName: "app", subsystemName: "api", computerName: "node-1", timestamp: second, text: "third"},
+			},
+			want: []wantResourceLogs{
+				{
+					attributes: map[string]string{"cx.application.name": "app", "cx.subsystem.name": "api", "host.name": "node-1"},
+					bodies:     []string{"first", "third"},
+				},
+				{
+					attributes: map[string]string{"cx.application.name": "other", "cx.subsystem.name": "api", "host.name": "node-1"},
+					bodies:     []string{"second"},
+				},
+			},
+		},
+		{
+			name: "multi-byte lengths and text",
+			records: []logRecord{
+				{applicationName: "app", subsystemName: "sub", computerName: "host", timestamp: first, text: string(make([]byte, 300)) + "héllo"},
+			},
+			want: []wantResourceLogs{
+				{
+					attributes: map[string]string{"cx.application.name": "app", "cx.subsystem.name": "sub", "host.name": "host"},
+					bodies:     []string{string(make([]byte, 300)) + "héllo"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var request collogspb.ExportLogsServiceRequest
+			if err := proto.Unmarshal(encodeOTLPLogs(test.records, observed), &request); err != nil {
+				t.Fatalf("proto.Unmarshal() error = %v", err)
+			}
+
+			if len(request.ResourceLogs) != len(test.want) {
+				t.Fatalf("len(ResourceLogs) = %d, want %d", len(request.ResourceLogs), len(test.want))
+			}
+			for i, resourceLogs := range request.ResourceLogs {
+				want := test.want[i]
+
+				attributes := map[string]string{}
+				for _, attribute := range resourceLogs.Resource.Attributes {
+					attributes[attribute.Key] = attribute.Value.GetStringValue()
+				}
+				if len(attributes) != len(want.attributes) {
+					t.Fatalf("ResourceLogs[%d] attributes = %v, want %v", i, attributes, want.attributes)
+				}
+				for key, value := range want.attributes {
+					if attributes[key] != value {
+						t.Fatalf("ResourceLogs[%d] attributes = %v, want %v", i, attributes, want.attributes)
+					}
+				}
+
+				if len(resourceLogs.ScopeLogs) != 1 {
+					t.Fatalf("len(ResourceLogs[%d].ScopeLogs) = %d, want 1", i, len(resourceLogs.ScopeLogs))
+				}
+				logRecords := resourceLogs.ScopeLogs[0].LogRecords
+				if len(logRecords) != len(want.bodies) {
+					t.Fatalf("len(ResourceLogs[%d] records) = %d, want %d", i, len(logRecords), len(want.bodies))
+				}
+				for j, logRecord := range logRecords {
+					if got := logRecord.Body.GetStringValue(); got != want.bodies[j] {
+						t.Fatalf("record %d/%d body = %q, want %q", i, j, got, want.bodies[j])
+					}
+					if logRecord.ObservedTimeUnixNano != uint64(observed.UnixNano()) {
+						t.Fatalf("record %d/%d ObservedTimeUnixNano = %d, want %d", i, j, logRecord.ObservedTimeUnixNano, observed.UnixNano())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeOTLPLogsTimestamps(t *testing.T) {
+	timestamp := time.Unix(1700000000, 123456789)
+	records := []logRecord{{applicationName: "app", subsystemName: "sub", computerName: "host", timestamp: timestamp, text: "line"}}
+
+	var request collogspb.ExportLogsServiceRequest
+	if err := proto.Unmarshal(encodeOTLPLogs(records, timestamp.Add(time.Second)), &request); err != nil {
+		t.Fatalf("proto.Unmarshal() error = %v", err)
+	}
+
+	logRecord := request.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if logRecord.TimeUnixNano != uint64(timestamp.UnixNano()) {
+		t.Fatalf("TimeUnixNano = %d, want %d", logRecord.TimeUnixNano, timestamp.UnixNano())
+	}
+	if want := uint64(timestamp.Add(time.Second).UnixNano()); logRecord.ObservedTimeUnixNano != want {
+		t.Fatalf("ObservedTimeUnixNano = %d, want %d", logRecord.ObservedTimeUnixNano, want)
+	}
+}
+
+type wantResourceLogs struct {
+	attributes map[string]string
+	bodies     []string
+}
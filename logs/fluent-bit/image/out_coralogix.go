@@ -3,12 +3,15 @@ package main
 import (
 	"C"
 	"bytes"
-	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 	"unsafe"
 )
@@ -21,6 +24,36 @@ import (
 	"github.com/thedevsaddam/gojsonq"
 )
 
+// Supported values of the Protocol option
+const (
+	protocolREST = "rest"
+	protocolOTLP = "otlp"
+)
+
+// instance is the context of one coralogix output
+type instance struct {
+	config          map[string]string
+	queue           *diskQueue
+	maxBatchRecords int
+	maxBatchBytes   int64
+	maxConcurrency  int
+	maxRetries      int
+	statuses        statusCounters
+	client          *http.Client
+	sent            sentBatches
+	logURL          string
+	otlpURL         string
+}
+
+// logRecord is a Fluent-Bit record with its Coralogix metadata
+type logRecord struct {
+	applicationName string
+	subsystemName   string
+	computerName    string
+	timestamp       time.Time
+	text            string
+}
+
 //export FLBPluginRegister
 func FLBPluginRegister(def unsafe.Pointer) int {
 	return output.FLBPluginRegister(def, "coralogix", "Send output to Coralogix")
@@ -39,14 +72,40 @@ func FLBPluginInit(plugin unsafe.Pointer) int {
 	logKey := output.FLBPluginConfigKey(plugin, "Log_Key")
 	hostKey := output.FLBPluginConfigKey(plugin, "Host_Key")
 	debug := output.FLBPluginConfigKey(plugin, "Debug")
+	protocol := strings.ToLower(output.FLBPluginConfigKey(plugin, "Protocol"))
+	storagePath := output.FLBPluginConfigKey(plugin, "Storage_Path")
+	storageMaxSize := output.FLBPluginConfigKey(plugin, "Storage_Max_Size")
+	storageMaxAge := output.FLBPluginConfigKey(plugin, "Storage_Max_Age")
+	maxBatchRecords := output.FLBPluginConfigKey(plugin, "Max_Batch_Records")
+	maxBatchBytes := output.FLBPluginConfigKey(plugin, "Max_Batch_Bytes")
+	maxConcurrency := output.FLBPluginConfigKey(plugin, "Max_Concurrency")
+	maxRetries := output.FLBPluginConfigKey(plugin, "Max_Retries")
+	tlsCAFile := output.FLBPluginConfigKey(plugin, "TLS_CA_File")
+	tlsVerify := output.FLBPluginConfigKey(plugin, "TLS_Verify")
+	tlsCertFile := output.FLBPluginConfigKey(plugin, "TLS_Cert_File")
+	tlsKeyFile := output.FLBPluginConfigKey(plugin, "TLS_Key_File")
+	httpProxy := output.FLBPluginConfigKey(plugin, "HTTP_Proxy")
+	noProxy := output.FLBPluginConfigKey(plugin, "NO_PROXY")
+	timeout := output.FLBPluginConfigKey(plugin, "Timeout")
 
 	// Debug output
 	log.SetPrefix("[CORALOGIX] ")
 	log.Println("Initialize sending to Coralogix...")
 	log.Printf("Private_Key = ********-****-****-****-******%s\n", privateKey[len(privateKey)-6:])
 
+	// Check protocol
+	if protocol == "" {
+		protocol = protocolREST
+	}
+	if protocol != protocolREST && protocol != protocolOTLP {
+		log.Printf(" ERROR: invalid Protocol %q, expected %q or %q!\n", protocol, protocolREST, protocolOTLP)
+		return output.FLB_ERROR
+	}
+
 	// Check Coralogix endpoint
-	if endpoint == "" {
+	if endpoint == "" && protocol == protocolOTLP {
+		endpoint = "ingress.coralogix.com"
+	} else if endpoint == "" {
 		endpoint = "api.coralogix.com"
 	}
 
@@ -73,7 +132,7 @@ func FLBPluginInit(plugin unsafe.Pointer) int {
 	}
 
 	// Pass output configuration to context
-	output.FLBPluginSetContext(plugin, map[string]string{
+	inst := &instance{config: map[string]string{
 		"endpoint":     endpoint,
 		"private_key":  privateKey,
 		"app_name":     appName,
@@ -84,7 +143,111 @@ func FLBPluginInit(plugin unsafe.Pointer) int {
 		"log_key":      logKey,
 		"host_key":     hostKey,
 		"debug":        debug,
-	})
+		"protocol":     protocol,
+	}, maxConcurrency: defaultMaxConcurrency, maxRetries: defaultMaxRetries}
+
+	// Check batch limits
+	if maxBatchRecords != "" {
+		records, err := strconv.Atoi(maxBatchRecords)
+		if err != nil || records < 0 {
+			log.Printf(" ERROR: invalid Max_Batch_Records %q!\n", maxBatchRecords)
+			return output.FLB_ERROR
+		}
+		inst.maxBatchRecords = records
+	}
+	if maxBatchBytes != "" {
+		size, err := parseSize(maxBatchBytes)
+		if err != nil {
+			log.Printf(" ERROR: invalid Max_Batch_Bytes: %v\n", err)
+			return output.FLB_ERROR
+		}
+		inst.maxBatchBytes = size
+	}
+	if maxConcurrency != "" {
+		concurrency, err := strconv.Atoi(maxConcurrency)
+		if err != nil || concurrency < 1 {
+			log.Printf(" ERROR: invalid Max_Concurrency %q!\n", maxConcurrency)
+			return output.FLB_ERROR
+		}
+		inst.maxConcurrency = concurrency
+	}
+
+	// Check retries
+	if maxRetries != "" {
+		retries, err := strconv.Atoi(maxRetries)
+		if err != nil || retries < 0 {
+			log.Printf(" ERROR: invalid Max_Retries %q!\n", maxRetries)
+			return output.FLB_ERROR
+		}
+		inst.maxRetries = retries
+	}
+
+	// Build HTTP client
+	clientOptions := clientOptions{
+		caFile:         tlsCAFile,
+		verify:         !strings.EqualFold(tlsVerify, "Off"),
+		certFile:       tlsCertFile,
+		keyFile:        tlsKeyFile,
+		proxy:          httpProxy,
+		noProxy:        noProxy,
+		timeout:        defaultTimeout,
+		maxConcurrency: inst.maxConcurrency,
+	}
+	if timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil || duration <= 0 {
+			log.Printf(" ERROR: invalid Timeout %q!\n", timeout)
+			return output.FLB_ERROR
+		}
+		clientOptions.timeout = duration
+	}
+	if !clientOptions.verify {
+		log.Println(" WARNING: TLS_Verify is Off, the Coralogix certificate is not checked!")
+	}
+	client, err := newHTTPClient(clientOptions)
+	if err != nil {
+		log.Printf(" ERROR: %v\n", err)
+		return output.FLB_ERROR
+	}
+	inst.client = client
+
+	// Get Coralogix endpoint URL overrides, one per protocol
+	inst.logURL = os.Getenv("CORALOGIX_LOG_URL")
+	inst.otlpURL = os.Getenv("CORALOGIX_OTLP_URL")
+
+	// Open storage queue
+	if storagePath != "" {
+		maxSize := int64(defaultStorageMaxSize)
+		if storageMaxSize != "" {
+			size, err := parseSize(storageMaxSize)
+			if err != nil {
+				log.Printf(" ERROR: invalid Storage_Max_Size: %v\n", err)
+				return output.FLB_ERROR
+			}
+			maxSize = size
+		}
+
+		maxAge := defaultStorageMaxAge
+		if storageMaxAge != "" {
+			age, err := time.ParseDuration(storageMaxAge)
+			if err != nil {
+				log.Printf(" ERROR: invalid Storage_Max_Age: %v\n", err)
+				return output.FLB_ERROR
+			}
+			maxAge = age
+		}
+
+		queue, err := newDiskQueue(storagePath, maxSize, maxAge, func(protocol string, payload []byte) error {
+			return inst.sendBatch(protocol, payload)
+		})
+		if err != nil {
+			log.Printf(" ERROR: cannot open Storage_Path %s: %v\n", storagePath, err)
+			return output.FLB_ERROR
+		}
+		inst.queue = queue
+	}
+
+	output.FLBPluginSetContext(plugin, inst)
 
 	return output.FLB_OK
 }
@@ -97,13 +260,8 @@ func FLBPluginFlush(data unsafe.Pointer, length C.int, tag *C.char) int {
 //export FLBPluginFlushCtx
 func FLBPluginFlushCtx(ctx, data unsafe.Pointer, length C.int, tag *C.char) int {
 	// Get plugin instance configuration
-	config := output.FLBPluginGetContext(ctx).(map[string]string)
-
-	// Get Coralogix endpoint URL
-	endpoint, exists := os.LookupEnv("CORALOGIX_LOG_URL")
-	if !exists {
-		endpoint = fmt.Sprintf("https://%s/logs/rest/singles", config["endpoint"])
-	}
+	inst := output.FLBPluginGetContext(ctx).(*instance)
+	config := inst.config
 
 	// Get hostname
 	hostname, err := os.Hostname()
@@ -115,7 +273,7 @@ func FLBPluginFlushCtx(ctx, data unsafe.Pointer, length C.int, tag *C.char) int
 	decoder := output.NewDecoder(data, int(length))
 
 	// Build records batch
-	var batch []interface{}
+	var batch []logRecord
 	for {
 		// Extract record
 		ret, _, record := output.GetRecord(decoder)
@@ -137,53 +295,193 @@ func FLBPluginFlushCtx(ctx, data unsafe.Pointer, length C.int, tag *C.char) int
 		}
 
 		// Add record to batch
-		batch = append(batch, map[string]interface{}{
-			"applicationName": extractField(jsonRecord, config["app_name_key"], config["app_name"]),
-			"subsystemName":   extractField(jsonRecord, config["sub_name_key"], config["sub_name"]),
-			"computerName":    extractField(jsonRecord, config["host_key"], hostname),
-			"timestamp":       timestamp.UnixNano() / 1000000,
-			"text":            extractField(jsonRecord, config["log_key"], jsonRecord),
+		batch = append(batch, logRecord{
+			applicationName: extractField(jsonRecord, config["app_name_key"], config["app_name"]),
+			subsystemName:   extractField(jsonRecord, config["sub_name_key"], config["sub_name"]),
+			computerName:    extractField(jsonRecord, config["host_key"], hostname),
+			timestamp:       timestamp,
+			text:            extractField(jsonRecord, config["log_key"], jsonRecord),
 		})
 	}
-	jsonBatch, _ := jsoniter.Marshal(batch)
 
-	// Compress data
-	var buffer bytes.Buffer
-	zipper, err := gzip.NewWriterLevel(&buffer, 9)
-	zipper.Write(jsonBatch)
-	zipper.Close()
-	if err != nil {
-		log.Println(" ERROR: cannot compress the data:", err)
-		return output.FLB_RETRY
+	// Split, encode and compress records batch. Nothing is sent when no
+	// record could be converted.
+	batches := splitBatch(batch, config["protocol"], inst.maxBatchRecords, inst.maxBatchBytes)
+	if len(batches) == 0 {
+		return output.FLB_OK
+	}
+	payloads := make([][]byte, len(batches))
+	for i, records := range batches {
+		payloads[i], err = compressBatch(encodeBatch(config["protocol"], records))
+		if err != nil {
+			log.Println(" ERROR: cannot compress the data:", err)
+			return output.FLB_RETRY
+		}
 	}
 
-	// Build request
-	request, err := http.NewRequest(http.MethodPost, endpoint, &buffer)
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("Content-Encoding", "gzip")
-	request.Header.Set("private_key", config["private_key"])
-	if err != nil {
-		log.Println(" ERROR: cannot build request:", err)
-		return output.FLB_RETRY
+	return inst.flushBatches(sha256.Sum256(C.GoBytes(data, length)), batches, payloads)
+}
+
+// flushBatches sends the compressed sub-batches of a chunk, identified by
+// the hash of its content, and returns the status handed back to Fluent-Bit
+func (inst *instance) flushBatches(chunk [sha256.Size]byte, batches [][]logRecord, payloads [][]byte) int {
+	config := inst.config
+	records := 0
+	for _, batch := range batches {
+		records += len(batch)
+	}
+
+	// Fluent-Bit retries the whole chunk, so skip the sub-batches an earlier
+	// attempt already sent, dropped or queued
+	done := make([]bool, len(payloads))
+	if sent := inst.sent.take(chunk, len(payloads)); sent != nil {
+		done = sent
+	}
+	var pending [][]byte
+	var pendingIndexes []int
+	for i, payload := range payloads {
+		if !done[i] {
+			pending = append(pending, payload)
+			pendingIndexes = append(pendingIndexes, i)
+		}
+	}
+
+	// Queue behind earlier failed batches to keep them in order
+	if inst.queue != nil && !inst.queue.Empty() {
+		status := enqueueBatches(inst, pending)
+		if status != output.FLB_OK {
+			inst.sent.remember(chunk, done)
+		}
+		return status
 	}
 
 	// Send records batch
 	if config["debug"] == "On" {
-		log.Printf(" INFO: Sending %d records...\n", len(batch))
+		log.Printf(" INFO: Sending %d records in %d of %d requests...\n", records, len(pending), len(payloads))
 	}
-	client := &http.Client{Timeout: 30 * time.Second}
-	response, err := client.Do(request)
-	if err != nil {
-		log.Println(" ERROR: cannot send logs batch:", err)
-		return output.FLB_RETRY
-	} else if response.StatusCode != 200 {
-		log.Println(" ERROR: cannot send logs batch:", response.StatusCode)
+	errs := make([]error, len(payloads))
+	for i, err := range sendBatches(inst, pending) {
+		errs[pendingIndexes[i]] = err
+	}
+	if config["debug"] == "On" {
+		log.Printf(" INFO: Responses by status: %s\n", inst.statuses.String())
+	}
+
+	// Account for failed sub-batches, dropping the rejected ones
+	var failed [][]byte
+	failedRecords := 0
+	dropped := 0
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if isPermanent(err) {
+			log.Printf(" ERROR: dropping logs batch %d/%d of %d records: %v\n", i+1, len(payloads), len(batches[i]), err)
+			log.Printf(" ERROR: first dropped record: %s\n", sample(batches[i][0].text))
+			dropped++
+			continue
+		}
+		log.Printf(" ERROR: cannot send logs batch %d/%d: %v\n", i+1, len(payloads), err)
+		failed = append(failed, payloads[i])
+		failedRecords += len(batches[i])
+	}
+	if len(failed) == 0 && dropped == 0 {
+		return output.FLB_OK
+	}
+	if len(payloads) > 1 {
+		log.Printf(" ERROR: %d of %d requests failed (%d of %d records), %d dropped\n", len(failed)+dropped, len(payloads), failedRecords, records, dropped)
+	}
+
+	if len(failed) == 0 {
+		return output.FLB_ERROR
+	}
+	if inst.queue != nil && enqueueBatches(inst, failed) == output.FLB_OK {
+		return output.FLB_OK
+	}
+
+	// Remember the sent and dropped sub-batches for the retry
+	for i, err := range errs {
+		done[i] = done[i] || err == nil || isPermanent(err)
+	}
+	inst.sent.remember(chunk, done)
+	return output.FLB_RETRY
+}
+
+// enqueueBatches hands compressed batches over to the storage queue. Either
+// all of them are queued or none, so a retry of the chunk queues no batch
+// twice.
+func enqueueBatches(inst *instance, payloads [][]byte) int {
+	if err := inst.queue.Push(inst.config["protocol"], payloads...); err != nil {
+		log.Println(" ERROR: cannot queue logs batches:", err)
 		return output.FLB_RETRY
 	}
+	if inst.config["debug"] == "On" {
+		log.Printf(" INFO: Queued %d logs batches in %s\n", len(payloads), inst.queue.dir)
+	}
 
 	return output.FLB_OK
 }
 
+// sendBatch posts a compressed batch encoded for protocol once and counts
+// the response status
+func (inst *instance) sendBatch(protocol string, payload []byte) error {
+	config := inst.config
+
+	// Build request
+	request, err := http.NewRequest(http.MethodPost, inst.endpointURL(protocol), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("cannot build request: %w", err)
+	}
+	request.Header.Set("Content-Encoding", "gzip")
+	if protocol == protocolOTLP {
+		request.Header.Set("Content-Type", "application/x-protobuf")
+		request.Header.Set("Authorization", "Bearer "+config["private_key"])
+	} else {
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("private_key", config["private_key"])
+	}
+
+	// Send request
+	response, err := inst.client.Do(request)
+	if err != nil {
+		inst.statuses.add(0)
+		return &sendError{err: err}
+	}
+	defer func() {
+		// Drain the body so the connection can be reused
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}()
+	inst.statuses.add(response.StatusCode)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, sampleLength))
+		return &sendError{
+			status:     response.StatusCode,
+			retryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+			body:       sample(strings.TrimSpace(string(body))),
+		}
+	}
+
+	return nil
+}
+
+// endpointURL returns the URL batches encoded for protocol are posted to.
+// CORALOGIX_LOG_URL overrides the REST endpoint and CORALOGIX_OTLP_URL the
+// OTLP one.
+func (inst *instance) endpointURL(protocol string) string {
+	if protocol == protocolOTLP {
+		if inst.otlpURL != "" {
+			return inst.otlpURL
+		}
+		return fmt.Sprintf("https://%s/v1/logs", inst.config["endpoint"])
+	}
+
+	if inst.logURL != "" {
+		return inst.logURL
+	}
+	return fmt.Sprintf("https://%s/logs/rest/singles", inst.config["endpoint"])
+}
+
 //export FLBPluginExit
 func FLBPluginExit() int {
 	return output.FLB_OK
@@ -191,9 +489,34 @@ func FLBPluginExit() int {
 
 //export FLBPluginExitCtx
 func FLBPluginExitCtx(ctx unsafe.Pointer) int {
+	// Stop replaying, queued batches stay on disk
+	inst, ok := output.FLBPluginGetContext(ctx).(*instance)
+	if !ok {
+		return output.FLB_OK
+	}
+	if inst.queue != nil {
+		inst.queue.Close()
+	}
+	log.Printf(" INFO: Responses by status: %s\n", inst.statuses.String())
+
 	return output.FLB_OK
 }
 
+// restBatch converts records to the body of the /logs/rest/singles endpoint
+func restBatch(records []logRecord) []interface{} {
+	var batch []interface{}
+	for _, record := range records {
+		batch = append(batch, map[string]interface{}{
+			"applicationName": record.applicationName,
+			"subsystemName":   record.subsystemName,
+			"computerName":    record.computerName,
+			"timestamp":       record.timestamp.UnixNano() / 1000000,
+			"text":            record.text,
+		})
+	}
+	return batch
+}
+
 // toStringMap recursively goes through the slice and converts []byte
 // to string so that jsonitor.MarshalToString/json.Marshal don't
 // encode []byte to Base64.
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		logURL   string
+		otlpURL  string
+		protocol string
+		want     string
+	}{
+		{name: "rest", protocol: protocolREST, want: "https://ingress.example.com/logs/rest/singles"},
+		{name: "otlp", protocol: protocolOTLP, want: "https://ingress.example.com/v1/logs"},
+		{name: "rest override", logURL: "http://proxy/logs", protocol: protocolREST, want: "http://proxy/logs"},
+		{name: "rest override ignored by otlp", logURL: "http://proxy/logs", protocol: protocolOTLP, want: "https://ingress.example.com/v1/logs"},
+		{name: "otlp override", logURL: "http://proxy/logs", otlpURL: "http://gateway:4318/v1/logs", protocol: protocolOTLP, want: "http://gateway:4318/v1/logs"},
+		{name: "otlp override ignored by rest", otlpURL: "http://gateway:4318/v1/logs", protocol: protocolREST, want: "https://ingress.example.com/logs/rest/singles"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inst := &instance{config: map[string]string{"endpoint": "ingress.example.com"}, logURL: test.logURL, otlpURL: test.otlpURL}
+			if got := inst.endpointURL(test.protocol); got != test.want {
+				t.Fatalf("endpointURL(%q) = %q, want %q", test.protocol, got, test.want)
+			}
+		})
+	}
+}
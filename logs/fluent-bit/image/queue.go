@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage queue defaults and replay backoff
+const (
+	defaultStorageMaxSize = 100 * 1024 * 1024
+	defaultStorageMaxAge  = 24 * time.Hour
+	queueRetryMin         = time.Second
+	queueRetryMax         = time.Minute
+	queueFileSuffix       = ".gz"
+)
+
+// queuedBatch is a compressed batch persisted in the storage directory. The
+// file name holds the enqueue time, a sequence number and the protocol, so
+// the queue can be rebuilt in order after a restart.
+type queuedBatch struct {
+	name     string
+	size     int64
+	queued   time.Time
+	protocol string
+}
+
+// diskQueue is a bounded write-ahead queue of batches that could not be
+// sent. A background goroutine replays them oldest first. When the queue
+// grows over maxSize or a batch gets older than maxAge, the oldest batches
+// are dropped.
+type diskQueue struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	send    func(protocol string, payload []byte) error
+
+	mutex   sync.Mutex
+	batches []queuedBatch
+	size    int64
+	seq     int
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newDiskQueue opens the queue in dir, keeping the batches persisted by a
+// previous run, and starts replaying them
+func newDiskQueue(dir string, maxSize int64, maxAge time.Duration, send func(protocol string, payload []byte) error) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create storage directory: %w", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read storage directory: %w", err)
+	}
+
+	queue := &diskQueue{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		send:    send,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, file := range files {
+		// Remove batches whose write was interrupted
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		batch, ok := parseQueuedBatch(file.Name(), info.Size())
+		if !ok {
+			continue
+		}
+		queue.batches = append(queue.batches, batch)
+		queue.size += batch.size
+	}
+	sort.Slice(queue.batches, func(i, j int) bool {
+		return queue.batches[i].name < queue.batches[j].name
+	})
+
+	queue.mutex.Lock()
+	queue.enforceLimits(time.Now())
+	queue.mutex.Unlock()
+
+	if len(queue.batches) > 0 {
+		log.Printf(" INFO: replaying %d queued batches (%d bytes) from %s\n", len(queue.batches), queue.size, dir)
+	}
+
+	go queue.run()
+	return queue, nil
+}
+
+// parseQueuedBatch reads a file name written by Push
+func parseQueuedBatch(name string, size int64) (queuedBatch, bool) {
+	if !strings.HasSuffix(name, queueFileSuffix) {
+		return queuedBatch{}, false
+	}
+	parts := strings.Split(strings.TrimSuffix(name, queueFileSuffix), ".")
+	if len(parts) != 2 {
+		return queuedBatch{}, false
+	}
+	stamp := strings.Split(parts[0], "-")
+	if len(stamp) != 2 {
+		return queuedBatch{}, false
+	}
+	nanos, err := strconv.ParseInt(stamp[0], 10, 64)
+	if err != nil {
+		return queuedBatch{}, false
+	}
+
+	return queuedBatch{name: name, size: size, queued: time.Unix(0, nanos), protocol: parts[1]}, true
+}
+
+// Empty reports whether no batch is waiting
+func (q *diskQueue) Empty() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.batches) == 0
+}
+
+// Push persists compressed batches at the end of the queue, all of them or
+// none. Each batch is written to a temporary file first, so a crash never
+// leaves a partial batch. Batches that together exceed maxSize are refused
+// rather than dropped right away.
+func (q *diskQueue) Push(protocol string, payloads ...[]byte) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var size int64
+	for _, payload := range payloads {
+		size += int64(len(payload))
+	}
+	if q.maxSize > 0 && size > q.maxSize {
+		return fmt.Errorf("%d bytes of batches do not fit in a storage queue of %d bytes", size, q.maxSize)
+	}
+
+	now := time.Now()
+	var pushed []queuedBatch
+	for _, payload := range payloads {
+		q.seq = (q.seq + 1) % 1000000
+		name := fmt.Sprintf("%020d-%06d.%s%s", now.UnixNano(), q.seq, protocol, queueFileSuffix)
+		if err := writeQueueFile(filepath.Join(q.dir, name), payload); err != nil {
+			// Roll back, the caller retries every batch
+			for _, batch := range pushed {
+				os.Remove(filepath.Join(q.dir, batch.name))
+			}
+			return err
+		}
+		pushed = append(pushed, queuedBatch{name: name, size: int64(len(payload)), queued: now, protocol: protocol})
+	}
+
+	q.batches = append(q.batches, pushed...)
+	q.size += size
+	q.enforceLimits(now)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// writeQueueFile writes payload to path through a temporary file
+func writeQueueFile(path string, payload []byte) error {
+	if err := os.WriteFile(path+".tmp", payload, 0o644); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("cannot write queued batch: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("cannot write queued batch: %w", err)
+	}
+	return nil
+}
+
+// Close stops the replay. Batches still queued are kept on disk for the next
+// start.
+func (q *diskQueue) Close() {
+	close(q.stop)
+	<-q.done
+}
+
+// enforceLimits drops the oldest batches while the queue is too large or
+// they are too old. The caller holds the mutex.
+func (q *diskQueue) enforceLimits(now time.Time) {
+	for len(q.batches) > 0 {
+		oldest := q.batches[0]
+		switch {
+		case q.maxSize > 0 && q.size > q.maxSize:
+			log.Printf(" WARNING: storage queue is over %d bytes, dropping batch %s\n", q.maxSize, oldest.name)
+		case q.maxAge > 0 && now.Sub(oldest.queued) > q.maxAge:
+			log.Printf(" WARNING: queued batch %s is older than %s, dropping it\n", oldest.name, q.maxAge)
+		default:
+			return
+		}
+		q.removeOldest()
+	}
+}
+
+// removeOldest deletes the first batch. The caller holds the mutex.
+func (q *diskQueue) removeOldest() {
+	oldest := q.batches[0]
+	if err := os.Remove(filepath.Join(q.dir, oldest.name)); err != nil && !os.IsNotExist(err) {
+		log.Printf(" WARNING: cannot remove queued batch %s: %v\n", oldest.name, err)
+	}
+	q.batches = q.batches[1:]
+	q.size -= oldest.size
+}
+
+// oldest returns the next batch to replay after dropping expired ones
+func (q *diskQueue) oldest() (queuedBatch, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.enforceLimits(time.Now())
+	if len(q.batches) == 0 {
+		return queuedBatch{}, false
+	}
+	return q.batches[0], true
+}
+
+// acknowledge removes batch once it was sent, unless it was dropped meanwhile
+func (q *diskQueue) acknowledge(batch queuedBatch) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.batches) > 0 && q.batches[0].name == batch.name {
+		q.removeOldest()
+	}
+}
+
+// run replays the queued batches in order, backing off while sending fails.
+// Rejected batches are dropped.
+func (q *diskQueue) run() {
+	defer close(q.done)
+
+	backoff := queueRetryMin
+	for {
+		batch, ok := q.oldest()
+		if !ok {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			}
+			continue
+		}
+
+		payload, err := os.ReadFile(filepath.Join(q.dir, batch.name))
+		if err != nil {
+			log.Printf(" WARNING: cannot read queued batch %s, dropping it: %v\n", batch.name, err)
+			q.acknowledge(batch)
+			continue
+		}
+
+		if err := q.send(batch.protocol, payload); isPermanent(err) {
+			log.Printf(" ERROR: queued batch %s was rejected, dropping it: %v\n", batch.name, err)
+			q.acknowledge(batch)
+			continue
+		} else if err != nil {
+			wait := backoff
+			if after := retryAfter(err); after > wait {
+				wait = after
+			}
+			log.Printf(" WARNING: cannot replay queued batch %s, retrying in %s: %v\n", batch.name, wait, err)
+			select {
+			case <-q.stop:
+				return
+			case <-time.After(wait):
+			}
+			backoff *= 2
+			if backoff > queueRetryMax {
+				backoff = queueRetryMax
+			}
+			continue
+		}
+
+		backoff = queueRetryMin
+		q.acknowledge(batch)
+	}
+}
+
+// parseSize reads a byte count with an optional K, M or G suffix
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return size * multiplier, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingSender records the payloads a queue replays
+type recordingSender struct {
+	mutex    sync.Mutex
+	payloads []string
+	err      error
+}
+
+func (s *recordingSender) send(protocol string, payload []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.payloads = append(s.payloads, protocol+":"+string(payload))
+	return nil
+}
+
+func (s *recordingSender) sent() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.payloads...)
+}
+
+// writeQueuedBatch writes a batch file as Push names it
+func writeQueuedBatch(t *testing.T, dir string, queued time.Time, seq int, protocol, payload string) string {
+	t.Helper()
+	name := fmt.Sprintf("%020d-%06d.%s%s", queued.UnixNano(), seq, protocol, queueFileSuffix)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(payload), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	return name
+}
+
+// waitForEmpty waits until the queue has replayed every batch
+func waitForEmpty(t *testing.T, queue *diskQueue) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !queue.Empty() {
+		if time.Now().After(deadline) {
+			t.Fatal("queue was not replayed in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func queueFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	return names
+}
+
+func TestDiskQueueReplaysInOrder(t *testing.T) {
+	dir := t.TempDir()
+	sender := &recordingSender{}
+	queue, err := newDiskQueue(dir, 0, 0, sender.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer queue.Close()
+
+	for _, payload := range []string{"first", "second", "third"} {
+		if err := queue.Push(protocolOTLP, []byte(payload)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	waitForEmpty(t, queue)
+
+	want := []string{"otlp:first", "otlp:second", "otlp:third"}
+	if got := sender.sent(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed = %v, want %v", got, want)
+	}
+	if files := queueFiles(t, dir); len(files) != 0 {
+		t.Fatalf("storage directory = %v, want empty", files)
+	}
+}
+
+func TestDiskQueueKeepsBatchesAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	failing := &recordingSender{err: &sendError{status: http.StatusServiceUnavailable}}
+	queue, err := newDiskQueue(dir, 0, 0, failing.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	for _, payload := range []string{"first", "second"} {
+		if err := queue.Push(protocolREST, []byte(payload)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	queue.Close()
+
+	if files := queueFiles(t, dir); len(files) != 2 {
+		t.Fatalf("storage directory after Close() = %v, want 2 batches", files)
+	}
+
+	sender := &recordingSender{}
+	queue, err = newDiskQueue(dir, 0, 0, sender.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() after restart error = %v", err)
+	}
+	defer queue.Close()
+	waitForEmpty(t, queue)
+
+	want := []string{"rest:first", "rest:second"}
+	if got := sender.sent(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed after restart = %v, want %v", got, want)
+	}
+}
+
+func TestDiskQueueSkipsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeQueuedBatch(t, dir, now, 1, protocolREST, "first")
+	writeQueuedBatch(t, dir, now, 3, protocolREST, "third")
+	for _, name := range []string{"partial.rest.gz.tmp", "notes.txt", "soon-000002.rest.gz", "00000000000000000001.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("junk"), 0o644); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+	// A batch that cannot be read is dropped
+	unreadable := fmt.Sprintf("%020d-%06d.%s%s", now.UnixNano(), 2, protocolREST, queueFileSuffix)
+	if err := os.Mkdir(filepath.Join(dir, unreadable), 0o755); err != nil {
+		t.Fatalf("os.Mkdir() error = %v", err)
+	}
+
+	sender := &recordingSender{}
+	queue, err := newDiskQueue(dir, 0, 0, sender.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer queue.Close()
+	waitForEmpty(t, queue)
+
+	if got, want := sender.sent(), []string{"rest:first", "rest:third"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed = %v, want %v", got, want)
+	}
+	// Interrupted writes are removed, unknown files are left alone
+	want := []string{"00000000000000000001.gz", "notes.txt", "soon-000002.rest.gz"}
+	if got := queueFiles(t, dir); !reflect.DeepEqual(got, want) {
+		t.Fatalf("storage directory = %v, want %v", got, want)
+	}
+}
+
+func TestDiskQueueDropsRejectedBatches(t *testing.T) {
+	dir := t.TempDir()
+	sender := &recordingSender{err: &sendError{status: http.StatusBadRequest}}
+	queue, err := newDiskQueue(dir, 0, 0, sender.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer queue.Close()
+
+	if err := queue.Push(protocolREST, []byte("rejected")); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	waitForEmpty(t, queue)
+
+	if files := queueFiles(t, dir); len(files) != 0 {
+		t.Fatalf("storage directory = %v, want empty", files)
+	}
+}
+
+func TestDiskQueueLimits(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeQueuedBatch(t, dir, now.Add(-2*time.Hour), 1, protocolREST, "expired")
+	writeQueuedBatch(t, dir, now.Add(-time.Minute), 2, protocolREST, "0123456789")
+	writeQueuedBatch(t, dir, now, 3, protocolREST, "0123456789")
+
+	failing := &recordingSender{err: &sendError{status: http.StatusServiceUnavailable}}
+	queue, err := newDiskQueue(dir, 25, time.Hour, failing.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer queue.Close()
+
+	if err := queue.Push(protocolREST, []byte("0123456789")); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	queue.mutex.Lock()
+	var names []string
+	for _, batch := range queue.batches {
+		names = append(names, batch.name)
+	}
+	size := queue.size
+	queue.mutex.Unlock()
+
+	// The expired batch goes at start, the oldest one when the third
+	// 10 byte batch exceeds 25 bytes
+	if len(names) != 2 || size != 20 {
+		t.Fatalf("queue = %v (%d bytes), want the 2 newest batches (20 bytes)", names, size)
+	}
+	if files := queueFiles(t, dir); !reflect.DeepEqual(files, names) {
+		t.Fatalf("storage directory = %v, want %v", files, names)
+	}
+}
+
+func TestDiskQueueRefusesBatchesOverMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	failing := &recordingSender{err: &sendError{status: http.StatusServiceUnavailable}}
+	queue, err := newDiskQueue(dir, 25, 0, failing.send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer queue.Close()
+
+	if err := queue.Push(protocolREST, []byte("0123456789")); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	queued := queueFiles(t, dir)
+
+	// Batches that cannot fit even in an empty queue are refused, not
+	// dropped
+	if err := queue.Push(protocolREST, []byte("0123456789012345678901234567890")); err == nil {
+		t.Fatal("Push() of a batch over Storage_Max_Size succeeded")
+	}
+	if err := queue.Push(protocolREST, []byte("0123456789"), []byte("0123456789"), []byte("0123456789")); err == nil {
+		t.Fatal("Push() of batches over Storage_Max_Size succeeded")
+	}
+	if files := queueFiles(t, dir); !reflect.DeepEqual(files, queued) {
+		t.Fatalf("storage directory = %v, want %v", files, queued)
+	}
+
+	// Batches that fit are queued together
+	if err := queue.Push(protocolREST, []byte("0123456789"), []byte("01234")); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if files := queueFiles(t, dir); len(files) != 3 {
+		t.Fatalf("storage directory = %v, want 3 batches", files)
+	}
+}
+
+func TestDiskQueueReplaysOneAtATime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		writeQueuedBatch(t, dir, now, i, protocolREST, fmt.Sprintf("batch %d", i))
+	}
+
+	var inFlight, maxInFlight int32
+	sender := &recordingSender{}
+	send := func(protocol string, payload []byte) error {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		if current > atomic.LoadInt32(&maxInFlight) {
+			atomic.StoreInt32(&maxInFlight, current)
+		}
+		time.Sleep(20 * time.Millisecond)
+		return sender.send(protocol, payload)
+	}
+
+	queue, err := newDiskQueue(dir, 0, 0, send)
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer queue.Close()
+	waitForEmpty(t, queue)
+
+	want := []string{"rest:batch 1", "rest:batch 2", "rest:batch 3"}
+	if got := sender.sent(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed = %v, want %v", got, want)
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got != 1 {
+		t.Fatalf("at most %d batches in flight, want 1", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "512", want: 512},
+		{value: "4K", want: 4 * 1024},
+		{value: " 100m ", want: 100 * 1024 * 1024},
+		{value: "2G", want: 2 * 1024 * 1024 * 1024},
+		{value: "", wantErr: true},
+		{value: "M", wantErr: true},
+		{value: "-1K", wantErr: true},
+		{value: "1.5M", wantErr: true},
+		{value: "10MB", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseSize(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseSize(%q) error = %v, want error %t", test.value, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("parseSize(%q) = %d, want %d", test.value, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retry defaults. A flush retries for at most retryFlushBudget, longer waits
+// are left to Fluent-Bit so that its worker is not held.
+const (
+	defaultMaxRetries = 3
+	retryBaseWait     = 500 * time.Millisecond
+	retryMaxWait      = 30 * time.Second
+	retryFlushBudget  = 30 * time.Second
+	sampleLength      = 256
+)
+
+// sleep waits between retries, replaced in tests
+var sleep = time.Sleep
+
+// sendError is a failed request. Status is zero when no response was
+// received.
+type sendError struct {
+	status     int
+	retryAfter time.Duration
+	body       string
+	err        error
+}
+
+func (e *sendError) Error() string {
+	if e.status == 0 {
+		return e.err.Error()
+	}
+	if e.body != "" {
+		return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+	}
+	return fmt.Sprintf("unexpected status %d", e.status)
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
+
+// permanent reports whether sending the batch again cannot succeed: a 4xx
+// response other than 429 means the batch or the private key was rejected
+func (e *sendError) permanent() bool {
+	return e.status >= 400 && e.status < 500 && e.status != http.StatusTooManyRequests
+}
+
+// isPermanent reports whether err is a permanent sendError
+func isPermanent(err error) bool {
+	var sendErr *sendError
+	return errors.As(err, &sendErr) && sendErr.permanent()
+}
+
+// retryAfter returns the wait requested by a 429 response, zero otherwise
+func retryAfter(err error) time.Duration {
+	var sendErr *sendError
+	if errors.As(err, &sendErr) && sendErr.status == http.StatusTooManyRequests {
+		return sendErr.retryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// sendWithRetry sends a batch, retrying 429 responses after their
+// Retry-After and 5xx responses and network errors with exponential backoff
+// and jitter. It gives up after maxRetries retries, on a permanent error and
+// when the wait would end after deadline.
+func (inst *instance) sendWithRetry(protocol string, payload []byte, deadline time.Time) error {
+	for attempt := 0; ; attempt++ {
+		err := inst.sendBatch(protocol, payload)
+		if err == nil || isPermanent(err) || attempt >= inst.maxRetries {
+			return err
+		}
+
+		wait := retryAfter(err)
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		if wait > time.Until(deadline) {
+			return err
+		}
+		if inst.config["debug"] == "On" {
+			log.Printf(" INFO: retrying logs batch in %s: %v\n", wait, err)
+		}
+		sleep(wait)
+	}
+}
+
+var (
+	jitterMutex  sync.Mutex
+	jitterSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// backoff returns a random wait between half and all of retryBaseWait
+// doubled attempt times
+func backoff(attempt int) time.Duration {
+	ceiling := retryBaseWait << uint(attempt)
+	if ceiling <= 0 || ceiling > retryMaxWait {
+		ceiling = retryMaxWait
+	}
+
+	jitterMutex.Lock()
+	defer jitterMutex.Unlock()
+	return ceiling/2 + time.Duration(jitterSource.Int63n(int64(ceiling/2)+1))
+}
+
+// statusCounters counts the responses of an output by HTTP status, with
+// network errors under status 0
+type statusCounters struct {
+	mutex  sync.Mutex
+	counts map[int]uint64
+}
+
+func (c *statusCounters) add(status int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[int]uint64)
+	}
+	c.counts[status]++
+}
+
+// String lists the counters by status, such as "network_error=2 200=12 429=1"
+func (c *statusCounters) String() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	statuses := make([]int, 0, len(c.counts))
+	for status := range c.counts {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	var counters []string
+	for _, status := range statuses {
+		name := strconv.Itoa(status)
+		if status == 0 {
+			name = "network_error"
+		}
+		counters = append(counters, fmt.Sprintf("%s=%d", name, c.counts[status]))
+	}
+	return strings.Join(counters, " ")
+}
+
+// sample shortens text for a log line
+func sample(text string) string {
+	if len(text) <= sampleLength {
+		return text
+	}
+	return text[:sampleLength] + "..."
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluent/fluent-bit-go/output"
+)
+
+// testResponse is one response of a test endpoint
+type testResponse struct {
+	status     int
+	retryAfter string
+}
+
+// newTestInstance returns an OTLP output posting to a server that answers
+// with responses in turn, repeating the last one, and a pointer to the
+// number of requests it received
+func newTestInstance(t *testing.T, responses ...testResponse) (*instance, *int) {
+	t.Helper()
+	var mutex sync.Mutex
+	requests := 0
+	inst := newServerInstance(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		response := responses[len(responses)-1]
+		if requests < len(responses) {
+			response = responses[requests]
+		}
+		requests++
+		mutex.Unlock()
+
+		if response.retryAfter != "" {
+			w.Header().Set("Retry-After", response.retryAfter)
+		}
+		w.WriteHeader(response.status)
+	})
+	return inst, &requests
+}
+
+// newServerInstance returns an OTLP output posting to a server running
+// handler
+func newServerInstance(t *testing.T, handler http.HandlerFunc) *instance {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &instance{
+		config:         map[string]string{"protocol": protocolOTLP, "private_key": "00000000-0000-0000-0000-000000000000"},
+		client:         server.Client(),
+		otlpURL:        server.URL,
+		maxConcurrency: 1,
+		maxRetries:     defaultMaxRetries,
+	}
+}
+
+// recordSleeps replaces the retry waits with a recorder for the test
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	original := sleep
+	sleep = func(wait time.Duration) { waits = append(waits, wait) }
+	t.Cleanup(func() { sleep = original })
+	return &waits
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "120", want: 2 * time.Minute},
+		{name: "seconds with spaces", value: " 3 ", want: 3 * time.Second},
+		{name: "zero seconds", value: "0", want: 0},
+		{name: "negative seconds", value: "-5", want: 0},
+		{name: "http date", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{name: "http date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseRetryAfter(test.value, now); got != test.want {
+				t.Fatalf("parseRetryAfter(%q) = %s, want %s", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{attempt: 0, ceiling: retryBaseWait},
+		{attempt: 1, ceiling: 2 * retryBaseWait},
+		{attempt: 3, ceiling: 8 * retryBaseWait},
+		{attempt: 6, ceiling: retryMaxWait},
+		{attempt: 40, ceiling: retryMaxWait},
+		{attempt: 70, ceiling: retryMaxWait},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			if got := backoff(test.attempt); got < test.ceiling/2 || got > test.ceiling {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", test.attempt, got, test.ceiling/2, test.ceiling)
+			}
+		}
+	}
+}
+
+func TestSendErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		permanent  bool
+		retryAfter time.Duration
+	}{
+		{name: "network error", err: &sendError{err: errors.New("connection refused")}},
+		{name: "bad request", err: &sendError{status: http.StatusBadRequest}, permanent: true},
+		{name: "forbidden", err: &sendError{status: http.StatusForbidden}, permanent: true},
+		{name: "too many requests", err: &sendError{status: http.StatusTooManyRequests, retryAfter: 5 * time.Second}, retryAfter: 5 * time.Second},
+		{name: "server error", err: &sendError{status: http.StatusBadGateway, retryAfter: 5 * time.Second}},
+		{name: "other error", err: errors.New("cannot build request")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isPermanent(test.err); got != test.permanent {
+				t.Fatalf("isPermanent() = %t, want %t", got, test.permanent)
+			}
+			if got := retryAfter(test.err); got != test.retryAfter {
+				t.Fatalf("retryAfter() = %s, want %s", got, test.retryAfter)
+			}
+		})
+	}
+}
+
+func TestFlushBatchesRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []testResponse
+		want      int
+		requests  int
+		// waits are the ceilings of the expected waits; backoff waits are
+		// at least half of theirs
+		waits    []time.Duration
+		backoff  bool
+		statuses string
+	}{
+		{
+			name:      "bad request is dropped",
+			responses: []testResponse{{status: http.StatusBadRequest}},
+			want:      output.FLB_ERROR,
+			requests:  1,
+			statuses:  "400=1",
+		},
+		{
+			name:      "too many requests honours Retry-After",
+			responses: []testResponse{{status: http.StatusTooManyRequests, retryAfter: "7"}, {status: http.StatusOK}},
+			want:      output.FLB_OK,
+			requests:  2,
+			waits:     []time.Duration{7 * time.Second},
+			statuses:  "200=1 429=1",
+		},
+		{
+			name:      "server errors back off",
+			responses: []testResponse{{status: http.StatusServiceUnavailable}, {status: http.StatusBadGateway}, {status: http.StatusOK}},
+			want:      output.FLB_OK,
+			requests:  3,
+			waits:     []time.Duration{retryBaseWait, 2 * retryBaseWait},
+			backoff:   true,
+			statuses:  "200=1 502=1 503=1",
+		},
+		{
+			name:      "retries run out",
+			responses: []testResponse{{status: http.StatusInternalServerError}},
+			want:      output.FLB_RETRY,
+			requests:  defaultMaxRetries + 1,
+			waits:     []time.Duration{retryBaseWait, 2 * retryBaseWait, 4 * retryBaseWait},
+			backoff:   true,
+			statuses:  "500=" + strconv.Itoa(defaultMaxRetries+1),
+		},
+		{
+			name:      "Retry-After beyond the flush budget",
+			responses: []testResponse{{status: http.StatusTooManyRequests, retryAfter: "60"}},
+			want:      output.FLB_RETRY,
+			requests:  1,
+			statuses:  "429=1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			waits := recordSleeps(t)
+			inst, requests := newTestInstance(t, test.responses...)
+
+			batches := [][]logRecord{{{text: "first"}}}
+			got := inst.flushBatches(sha256.Sum256([]byte(test.name)), batches, [][]byte{[]byte("first")})
+			if got != test.want {
+				t.Fatalf("flushBatches() = %d, want %d", got, test.want)
+			}
+			if *requests != test.requests {
+				t.Fatalf("server received %d requests, want %d", *requests, test.requests)
+			}
+			if len(*waits) != len(test.waits) {
+				t.Fatalf("waits = %v, want %d waits up to %v", *waits, len(test.waits), test.waits)
+			}
+			for i, wait := range *waits {
+				floor := test.waits[i]
+				if test.backoff {
+					floor /= 2
+				}
+				if wait < floor || wait > test.waits[i] {
+					t.Fatalf("wait %d = %s, want between %s and %s", i, wait, floor, test.waits[i])
+				}
+			}
+			if got := inst.statuses.String(); got != test.statuses {
+				t.Fatalf("statuses = %q, want %q", got, test.statuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package helmlog
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Sort orders the releases of c newest first, see Release.NewerThan.
+func Sort(c *Changelog) {
+	sort.SliceStable(c.Releases, func(i, j int) bool {
+		return c.Releases[i].NewerThan(c.Releases[j])
+	})
+}
+
+// NewerThan orders releases by date and then by version, newest first.
+func (r Release) NewerThan(other Release) bool {
+	if r.Date != other.Date {
+		return r.Date > other.Date
+	}
+
+	return CompareVersions(r.Version, other.Version) > 0
+}
+
+// Marshal encodes c as the indented JSON artifact written by helmlog
+// generate, ending with a newline. The output is byte-identical for equal
+// changelogs whatever Go version built helmlog: keys are sorted and line
+// breaks in entry text are written as "\n".
+func Marshal(c Changelog) ([]byte, error) {
+	output, err := encodeCanonical(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode changelog: %w", err)
+	}
+
+	return output, nil
+}
+
+// CanonicalReleaseDate accepts dates with or without zero padding and returns
+// them as YYYY-MM-DD.
+func CanonicalReleaseDate(date string) (string, error) {
+	parsed, err := time.Parse("2006-1-2", date)
+	if err != nil {
+		return "", fmt.Errorf("invalid release date %q", date)
+	}
+
+	return parsed.Format("2006-01-02"), nil
+}
+
+// validateReleaseDate rejects dates after the calendar day of now in its own
+// location.
+func validateReleaseDate(date string, now time.Time) error {
+	releaseDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return fmt.Errorf("invalid release date %q", date)
+	}
+
+	today, err := time.Parse("2006-01-02", now.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to determine current date: %w", err)
+	}
+
+	if releaseDate.After(today) {
+		return fmt.Errorf("release date must not be in the future: %s", date)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package helmlog
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+func TestSortNewestFirst(t *testing.T) {
+	log := Changelog{Releases: []Release{
+		{Version: "v1.1.0", Date: "2025-01-10"},
+		{Version: "v1.2.0", Date: "2026-01-01"},
+		{Version: "v1.3.0", Date: "2026-01-01"},
+	}}
+
+	Sort(&log)
+
+	if log.Releases[0].Version != "v1.3.0" {
+		t.Fatalf("first release = %q, want v1.3.0", log.Releases[0].Version)
+	}
+	if log.Releases[1].Version != "v1.2.0" {
+		t.Fatalf("second release = %q, want v1.2.0", log.Releases[1].Version)
+	}
+	if log.Releases[2].Version != "v1.1.0" {
+		t.Fatalf("third release = %q, want v1.1.0", log.Releases[2].Version)
+	}
+}
+
+func TestMarshalIndentsAndEndsWithNewline(t *testing.T) {
+	output, err := Marshal(Changelog{Releases: []Release{{Version: "v0.0.1", Date: "2026-01-01", Entries: []Entry{}}}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{
+  "releases": [
+    {
+      "date": "2026-01-01",
+      "entries": [],
+      "version": "v0.0.1"
+    }
+  ]
+}
+`
+	if string(output) != want {
+		t.Fatalf("Marshal() = %q, want %q", output, want)
+	}
+}
+
+func TestMarshalIsCanonical(t *testing.T) {
+	log := Changelog{Releases: []Release{{
+		Version: "v0.0.1",
+		Date:    "2026-01-01",
+		Entries: []Entry{{Tag: "Fix", Text: "Handle <a> & \"b\"\r\n- nested\rline\x01", Origin: OriginChart}},
+	}}}
+
+	output, err := Marshal(log)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{
+  "releases": [
+    {
+      "date": "2026-01-01",
+      "entries": [
+        {
+          "origin": "chart",
+          "tag": "Fix",
+          "text": "Handle <a> & \"b\"\n- nested\nline\u0001"
+        }
+      ],
+      "version": "v0.0.1"
+    }
+  ]
+}
+`
+	if string(output) != want {
+		t.Fatalf("Marshal() = %q, want %q", output, want)
+	}
+}
+
+// TestMarshalGolden pins the generate output of each testdata/golden/*.md.
+// Run go test ./pkg/helmlog -run TestMarshalGolden -update after an intended
+// change to the artifact.
+func TestMarshalGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "golden", "*.md"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no golden inputs found: %v", err)
+	}
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			content, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("os.ReadFile() error = %v", err)
+			}
+
+			goldenPath := strings.TrimSuffix(input, ".md") + ".json"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, generateGolden(t, string(content)), 0o644); err != nil {
+					t.Fatalf("os.WriteFile() error = %v", err)
+				}
+			}
+
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("os.ReadFile() error = %v", err)
+			}
+
+			// Line endings of the checkout must not change the artifact.
+			variants := map[string]string{
+				"LF":   string(content),
+				"CRLF": strings.ReplaceAll(string(content), "\n", "\r\n"),
+				"CR":   strings.ReplaceAll(string(content), "\n", "\r"),
+			}
+			for name, variant := range variants {
+				if output := generateGolden(t, variant); string(output) != string(golden) {
+					t.Fatalf("%s output differs from %s:\n%s", name, goldenPath, output)
+				}
+			}
+		})
+	}
+}
+
+func generateGolden(t *testing.T, content string) []byte {
+	t.Helper()
+
+	now := func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+	log, diagnostics := Parse(strings.NewReader(content), Options{Now: now})
+	if len(diagnostics) != 0 {
+		t.Fatalf("Parse() diagnostics = %v", diagnostics)
+	}
+
+	Sort(&log)
+	output, err := Marshal(log)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	return output
+}
+
+func TestValidateReleaseDateUsesLocalCalendarDay(t *testing.T) {
+	originalLocal := time.Local
+	t.Cleanup(func() {
+		time.Local = originalLocal
+	})
+
+	localZone := time.FixedZone("UTC+2", 2*60*60)
+	time.Local = localZone
+	now := time.Date(2026, 2, 10, 0, 30, 0, 0, localZone)
+
+	if err := validateReleaseDate("2026-02-10", now); err != nil {
+		t.Fatalf("validateReleaseDate() error = %v, want nil", err)
+	}
+
+	err := validateReleaseDate("2026-02-11", now)
+	if err == nil {
+		t.Fatal("expected future-date error, got nil")
+	}
+	if !strings.Contains(err.Error(), "release date must not be in the future") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package helmlog
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Chart is the part of a Helm Chart.yaml that helmlog reads.
+type Chart struct {
+	APIVersion   string            `json:"apiVersion" yaml:"apiVersion"`
+	Name         string            `json:"name" yaml:"name"`
+	Version      string            `json:"version" yaml:"version"`
+	AppVersion   string            `json:"appVersion,omitempty" yaml:"appVersion,omitempty"`
+	Dependencies []ChartDependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// ChartDependency is one entry of the Chart.yaml dependencies list.
+type ChartDependency struct {
+	Name       string `json:"name" yaml:"name"`
+	Version    string `json:"version" yaml:"version"`
+	Repository string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	Alias      string `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Condition  string `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// ParseChart decodes the content of a Chart.yaml file.
+func ParseChart(content []byte) (Chart, error) {
+	var chart Chart
+	if err := yaml.Unmarshal(content, &chart); err != nil {
+		return Chart{}, fmt.Errorf("invalid Chart.yaml: %w", err)
+	}
+
+	return chart, nil
+}
+
+// Dependency returns the dependency installed as name. An alias match wins
+// over a chart name match, so a chart that pulls the same dependency twice
+// under different aliases can be told apart; otherwise the first dependency
+// with that chart name is returned.
+func (c Chart) Dependency(name string) (ChartDependency, bool) {
+	for _, dependency := range c.Dependencies {
+		if dependency.Alias == name {
+			return dependency, true
+		}
+	}
+
+	for _, dependency := range c.Dependencies {
+		if dependency.Name == name {
+			return dependency, true
+		}
+	}
+
+	return ChartDependency{}, false
+}
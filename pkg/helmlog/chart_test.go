@@ -0,0 +1,63 @@
+package helmlog
+
+import "testing"
+
+func TestParseChartHandlesYAMLFeatures(t *testing.T) {
+	content := `# Chart for tests
+apiVersion: v2
+name: ecs-ec2-integration
+version: "0.0.43" # quoted on purpose
+appVersion: 0.135.4
+common: &repo https://cgx.jfrog.io/artifactory/coralogix-charts-virtual
+dependencies:
+  - {name: opentelemetry-collector, alias: opentelemetry-ebpf-profiler, version: "0.135.3", repository: *repo}
+  - name: opentelemetry-collector
+    # the agent is the primary deployment
+    alias: opentelemetry-agent
+    condition: opentelemetry-agent.enabled
+    repository: *repo
+    version: 0.135.4
+`
+
+	chart, err := ParseChart([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseChart() error = %v", err)
+	}
+
+	if chart.Name != "ecs-ec2-integration" || chart.Version != "0.0.43" || chart.AppVersion != "0.135.4" {
+		t.Fatalf("chart = %#v", chart)
+	}
+	if len(chart.Dependencies) != 2 {
+		t.Fatalf("len(Dependencies) = %d, want 2", len(chart.Dependencies))
+	}
+
+	agent, ok := chart.Dependency("opentelemetry-agent")
+	if !ok {
+		t.Fatalf("Dependency(opentelemetry-agent) not found")
+	}
+	want := ChartDependency{
+		Name:       "opentelemetry-collector",
+		Version:    "0.135.4",
+		Repository: "https://cgx.jfrog.io/artifactory/coralogix-charts-virtual",
+		Alias:      "opentelemetry-agent",
+		Condition:  "opentelemetry-agent.enabled",
+	}
+	if agent != want {
+		t.Fatalf("Dependency(opentelemetry-agent) = %#v, want %#v", agent, want)
+	}
+
+	collector, ok := chart.Dependency("opentelemetry-collector")
+	if !ok || collector.Alias != "opentelemetry-ebpf-profiler" {
+		t.Fatalf("Dependency(opentelemetry-collector) = %#v, want first dependency by chart name", collector)
+	}
+
+	if _, ok := chart.Dependency("opentelemetry-operator"); ok {
+		t.Fatalf("Dependency(opentelemetry-operator) found, want missing")
+	}
+}
+
+func TestParseChartRejectsInvalidYAML(t *testing.T) {
+	if _, err := ParseChart([]byte("version: [0.0.1\n")); err == nil {
+		t.Fatalf("ParseChart() error = nil, want error")
+	}
+}
@@ -0,0 +1,241 @@
+package helmlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Dialect names accepted by Options.Dialect. DialectAuto detects the dialect
+// from the content.
+const (
+	DialectAuto           = "auto"
+	DialectHelm           = "helm"
+	DialectSupervised     = "supervised"
+	DialectKeepAChangelog = "keep-a-changelog"
+	DialectLegacy         = "legacy"
+)
+
+// Dialect describes how one changelog flavour spells release headers and
+// entries. Parse owns the line loop and asks the dialect about everything
+// that differs between flavours.
+type Dialect struct {
+	Name string
+
+	// ReleaseHeader must capture the version and the date, in that order.
+	ReleaseHeader *regexp.Regexp
+	// HeaderFormat is shown in errors for lines that look like a release
+	// header but do not match ReleaseHeader.
+	HeaderFormat string
+	// HeaderTemplate is the fmt.Sprintf layout for a canonical release header
+	// given the version and the date.
+	HeaderTemplate string
+	// StrictHeaderPrefix marks lines that must be release headers. Empty
+	// means non-matching headings are treated as prose.
+	StrictHeaderPrefix string
+	// SkippedHeader matches headers whose entries are ignored until the next
+	// release header, such as Keep a Changelog's "## [Unreleased]".
+	SkippedHeader *regexp.Regexp
+
+	BulletMarkers []string
+
+	// SectionTags maps "### <Section>" headings to the tag given to the
+	// untagged entries below them. Nil means entries carry their own tags.
+	SectionTags map[string]string
+	// DefaultTag is used for top-level entries without a tag. Empty means
+	// every entry must be tagged.
+	DefaultTag string
+
+	Detect func(lines []string) bool
+}
+
+var (
+	releaseHeaderPattern        = regexp.MustCompile(`^### (v` + SemverExpr + `) / (\d{4}-\d{2}-\d{2})$`)
+	upstreamHeaderPattern       = regexp.MustCompile(`^#### Changes from ([A-Za-z0-9._-]+) (v?` + SemverExpr + `):?$`)
+	supervisedHeaderPattern     = regexp.MustCompile(`^## (v` + SemverExpr + `) - (\d{4}-\d{2}-\d{2})$`)
+	keepAChangelogHeaderPattern = regexp.MustCompile(`^## \[(v?` + SemverExpr + `)\] - (\d{4}-\d{2}-\d{2})$`)
+	keepAChangelogUnreleased    = regexp.MustCompile(`^## \[Unreleased\]`)
+	legacyHeaderPattern         = regexp.MustCompile(`^#{2,3} (v?` + SemverExpr + `) ?/ ?(\d{4}-\d{1,2}-\d{1,2})$`)
+)
+
+// dialects is ordered by detection priority. The helm dialect is the
+// historical format and acts as the fallback.
+var dialects = []*Dialect{
+	{
+		Name:           DialectKeepAChangelog,
+		ReleaseHeader:  keepAChangelogHeaderPattern,
+		HeaderFormat:   "## [X.Y.Z] - YYYY-MM-DD",
+		HeaderTemplate: "## [%s] - %s",
+		SkippedHeader:  keepAChangelogUnreleased,
+		BulletMarkers:  []string{"- ", "* "},
+		SectionTags: map[string]string{
+			"added":      "Feat",
+			"changed":    "Change",
+			"deprecated": "Change",
+			"removed":    "Breaking",
+			"fixed":      "Fix",
+			"security":   "Fix",
+		},
+		Detect: func(lines []string) bool {
+			return anyLineMatches(lines, keepAChangelogHeaderPattern) || anyLineMatches(lines, keepAChangelogUnreleased)
+		},
+	},
+	{
+		Name:               DialectSupervised,
+		ReleaseHeader:      supervisedHeaderPattern,
+		HeaderFormat:       "## vX.Y.Z - YYYY-MM-DD",
+		HeaderTemplate:     "## %s - %s",
+		StrictHeaderPrefix: "## ",
+		BulletMarkers:      []string{"- "},
+		Detect: func(lines []string) bool {
+			return anyLineMatches(lines, supervisedHeaderPattern)
+		},
+	},
+	{
+		Name:           DialectLegacy,
+		ReleaseHeader:  legacyHeaderPattern,
+		HeaderFormat:   "### vX.Y.Z / YYYY-MM-DD",
+		HeaderTemplate: "### %s / %s",
+		BulletMarkers:  []string{"- ", "* "},
+		DefaultTag:     "Change",
+		Detect:         detectLegacyDialect,
+	},
+	{
+		Name:               DialectHelm,
+		ReleaseHeader:      releaseHeaderPattern,
+		HeaderFormat:       "### vX.Y.Z / YYYY-MM-DD",
+		HeaderTemplate:     "### %s / %s",
+		StrictHeaderPrefix: "### ",
+		BulletMarkers:      []string{"- "},
+		Detect: func([]string) bool {
+			return true
+		},
+	},
+}
+
+// DialectNames lists the accepted dialect names, starting with DialectAuto.
+func DialectNames() []string {
+	names := []string{DialectAuto}
+	for _, d := range dialects {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
+
+// LookupDialect returns the dialect with the given name.
+func LookupDialect(name string) (*Dialect, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, d := range dialects {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown changelog dialect %q (expected one of %s)", name, strings.Join(DialectNames(), ", "))
+}
+
+// ResolveDialect returns the named dialect, or detects one from the content
+// when name is empty or "auto".
+func ResolveDialect(name string, lines []string) (*Dialect, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name != "" && name != DialectAuto {
+		return LookupDialect(name)
+	}
+
+	for _, d := range dialects {
+		if d.Detect(lines) {
+			return d, nil
+		}
+	}
+
+	return dialects[len(dialects)-1], nil
+}
+
+// detectLegacyDialect picks the legacy dialect when "*" bullets dominate or a
+// release header only parses with the relaxed legacy rules (missing "v",
+// unpadded dates, pre-release suffixes). An untagged entry is not a signal:
+// in a helm changelog it is a mistake that validation must report.
+func detectLegacyDialect(lines []string) bool {
+	starBullets := 0
+	dashBullets := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "* "):
+			starBullets++
+		case strings.HasPrefix(line, "- "):
+			dashBullets++
+		}
+
+		if legacyHeaderPattern.MatchString(trimmed) && !releaseHeaderPattern.MatchString("### "+strings.TrimLeft(trimmed, "# ")) {
+			return true
+		}
+	}
+
+	return starBullets > dashBullets
+}
+
+func anyLineMatches(lines []string, pattern *regexp.Regexp) bool {
+	for _, line := range lines {
+		if pattern.MatchString(strings.TrimSpace(line)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bulletText returns the text after a top-level or nested bullet marker.
+func (d *Dialect) bulletText(leftTrimmed string) (string, bool) {
+	for _, marker := range d.BulletMarkers {
+		if strings.HasPrefix(leftTrimmed, marker) {
+			return strings.TrimSpace(strings.TrimPrefix(leftTrimmed, marker)), true
+		}
+	}
+
+	return "", false
+}
+
+// sectionTag reports whether the line is a section heading and, if so, the
+// tag its entries receive. Unknown sections yield an empty tag.
+func (d *Dialect) sectionTag(trimmed string) (string, bool) {
+	if d.SectionTags == nil || !strings.HasPrefix(trimmed, "### ") {
+		return "", false
+	}
+
+	section := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "### ")))
+	return d.SectionTags[section], true
+}
+
+// FormatHeader rewrites a release header in the dialect's canonical layout.
+// Headers written at the wrong level or with unpadded dates, such as
+// "## v0.0.93 / 2024-8-6", are repaired when the result is a valid header.
+func (d *Dialect) FormatHeader(trimmed string) (string, bool) {
+	matches := d.ReleaseHeader.FindStringSubmatch(trimmed)
+	if matches == nil {
+		matches = legacyHeaderPattern.FindStringSubmatch(trimmed)
+	}
+	if matches == nil {
+		return "", false
+	}
+
+	date, err := CanonicalReleaseDate(matches[2])
+	if err != nil {
+		return "", false
+	}
+
+	header := fmt.Sprintf(d.HeaderTemplate, matches[1], date)
+	if !d.ReleaseHeader.MatchString(header) {
+		return "", false
+	}
+
+	return header, true
+}
+
+// IsGroupHeading reports whether a trimmed line is a "# " or "## " heading.
+// Releases under different group headings, such as "## Fluentd" and
+// "## Fluent-Bit", are versioned independently.
+func IsGroupHeading(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "# ") || strings.HasPrefix(trimmed, "## ")
+}
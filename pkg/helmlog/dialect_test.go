@@ -0,0 +1,72 @@
+package helmlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveDialectDetectsFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "helm",
+			input: "## otel-integration\n\n### v0.0.1 / 2026-01-01\n- [Feat] Initial release\n",
+			want:  DialectHelm,
+		},
+		{
+			name:  "supervised",
+			input: "# Changelog\n\n## v0.11.0 - 2026-07-15\n\n- [chore] Bump Supervisor to version 0.155.1.\n",
+			want:  DialectSupervised,
+		},
+		{
+			name:  "keep-a-changelog",
+			input: "# Changelog\n\n## [0.2.0] - 2026-03-31\n\n### Changed\n- Supervisor mode uses new artifacts\n",
+			want:  DialectKeepAChangelog,
+		},
+		{
+			name:  "legacy star bullets",
+			input: "## Fluentd\n\n### v1.16.5 / 2024-04-25\n* [CHANGE] Update the coralogix API\n",
+			want:  DialectLegacy,
+		},
+		{
+			name:  "helm with an untagged entry",
+			input: "### v0.0.2 / 2026-01-02\n- [Fix] Tagged\n- missing tag here\n\n### v0.0.1 / 2026-01-01\n- [Feat] Initial release\n",
+			want:  DialectHelm,
+		},
+		{
+			name:  "legacy unpadded date",
+			input: "### v1.18.0 / 2025-1-5\n- [Fix] Something\n",
+			want:  DialectLegacy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := readLines(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("readLines() error = %v", err)
+			}
+
+			got, err := ResolveDialect(DialectAuto, lines)
+			if err != nil {
+				t.Fatalf("ResolveDialect() error = %v", err)
+			}
+			if got.Name != tt.want {
+				t.Fatalf("dialect = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDialectUnknownName(t *testing.T) {
+	_, err := ResolveDialect("mkdocs", nil)
+	if err == nil {
+		t.Fatal("expected unknown dialect error, got nil")
+	}
+	if !strings.Contains(err.Error(), `unknown changelog dialect "mkdocs"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package helmlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// componentPattern matches a scope such as "spanMetrics: " or
+	// "presets/logsCollection: " at the start of an entry. Scopes are single
+	// words, so a sentence that happens to contain a colon is left alone.
+	componentPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_.\-/]*):\s+\S`)
+	// pullRequestsPattern matches trailing references such as "(#1234)" or
+	// "(#12, #34)".
+	pullRequestsPattern = regexp.MustCompile(`\s*\((#\d+(?:\s*,\s*#\d+)*)\)\s*$`)
+	// authorPattern matches GitHub handles. The @ must start a word, which
+	// keeps e-mail addresses and code such as "`@timestamp`" out.
+	authorPattern = regexp.MustCompile(`(?:^|[\s(])@([A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9]){0,38})\b`)
+)
+
+// ParseMetadata fills Component, PullRequests and Authors from Text. Text
+// itself is not changed. The component and pull requests are read from the
+// first line; authors may be mentioned on any line.
+func (e *Entry) ParseMetadata() {
+	first, _, _ := strings.Cut(e.Text, "\n")
+
+	e.Component = ""
+	if match := componentPattern.FindStringSubmatch(first); match != nil {
+		e.Component = match[1]
+	}
+
+	e.PullRequests = nil
+	for {
+		match := pullRequestsPattern.FindStringSubmatchIndex(first)
+		if match == nil {
+			break
+		}
+		refs := []int{}
+		for _, ref := range strings.Split(first[match[2]:match[3]], ",") {
+			number, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(ref), "#"))
+			if err == nil {
+				refs = append(refs, number)
+			}
+		}
+		// References are stripped from the end, so earlier groups come first.
+		e.PullRequests = append(refs, e.PullRequests...)
+		first = first[:match[0]]
+	}
+
+	e.Authors = nil
+	seen := map[string]bool{}
+	for _, match := range authorPattern.FindAllStringSubmatch(e.Text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			e.Authors = append(e.Authors, match[1])
+		}
+	}
+}
+
+// ParseMetadata runs Entry.ParseMetadata on every entry of the changelog,
+// including the copies kept under Release.Upstream.
+func (c *Changelog) ParseMetadata() {
+	for i := range c.Releases {
+		release := &c.Releases[i]
+		for j := range release.Entries {
+			release.Entries[j].ParseMetadata()
+		}
+		for j := range release.Upstream {
+			for k := range release.Upstream[j].Entries {
+				release.Upstream[j].Entries[k].ParseMetadata()
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package helmlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntryParseMetadata(t *testing.T) {
+	tests := []struct {
+		text string
+		want Entry
+	}{
+		{
+			text: "spanMetrics: add `seriesExpiration` option (#1234)",
+			want: Entry{Component: "spanMetrics", PullRequests: []int{1234}},
+		},
+		{
+			text: "presets/logsCollection: keep checkpoints (#12, #34) (#56)",
+			want: Entry{Component: "presets/logsCollection", PullRequests: []int{12, 34, 56}},
+		},
+		{
+			text: "Fix exporter retries, thanks @jane-doe and @bob (#7)\n- Reported by @jane-doe",
+			want: Entry{PullRequests: []int{7}, Authors: []string{"jane-doe", "bob"}},
+		},
+		{
+			text: "Send alerts to ops@coralogix.com and keep `@timestamp` (see #12 for details)",
+			want: Entry{},
+		},
+		{
+			text: "Important: This version contains a fix",
+			want: Entry{Component: "Important"},
+		},
+		{
+			text: "Bump collector to 0.135.4. See https://github.com/open-telemetry/opentelemetry-collector/releases",
+			want: Entry{},
+		},
+	}
+
+	for _, tt := range tests {
+		entry := Entry{Tag: "Feat", Text: tt.text}
+		entry.ParseMetadata()
+
+		tt.want.Tag = "Feat"
+		tt.want.Text = tt.text
+		if !reflect.DeepEqual(entry, tt.want) {
+			t.Fatalf("ParseMetadata(%q) = %#v, want %#v", tt.text, entry, tt.want)
+		}
+	}
+}
+
+func TestChangelogParseMetadataCoversUpstreamCopies(t *testing.T) {
+	entry := Entry{Tag: "Fix", Text: "k8sattributes: fix pod lookup (#99)", Origin: "opentelemetry-collector"}
+	log := Changelog{Releases: []Release{{
+		Version:  "v0.0.2",
+		Entries:  []Entry{entry},
+		Upstream: []UpstreamChangelog{{Chart: "opentelemetry-collector", Version: "0.135.4", Entries: []Entry{entry}}},
+	}}}
+
+	log.ParseMetadata()
+
+	for _, got := range []Entry{log.Releases[0].Entries[0], log.Releases[0].Upstream[0].Entries[0]} {
+		if got.Component != "k8sattributes" || !reflect.DeepEqual(got.PullRequests, []int{99}) {
+			t.Fatalf("entry = %#v, want component and pull request", got)
+		}
+	}
+}
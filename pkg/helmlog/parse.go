@@ -0,0 +1,425 @@
+package helmlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Options selects how a changelog is read.
+type Options struct {
+	// Dialect is one of DialectNames. Empty or DialectAuto detects it from the
+	// content.
+	Dialect string
+	// Taxonomy lists the accepted tags. Nil uses DefaultTaxonomyConfig.
+	Taxonomy *Taxonomy
+	// Now is used to reject release dates in the future. Nil uses time.Now.
+	Now func() time.Time
+	// Location decides which calendar day Now falls on. Nil uses time.Local.
+	Location *time.Location
+	// InitialDevelopment lets a release after a 0.0.z version carry breaking
+	// changes in a patch bump. By default every breaking release must bump
+	// the minor or major version.
+	InitialDevelopment bool
+}
+
+func (o Options) taxonomy() *Taxonomy {
+	if o.Taxonomy == nil {
+		return defaultTaxonomy
+	}
+
+	return o.Taxonomy
+}
+
+func (o Options) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+
+	return o.Now()
+}
+
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+
+	return o.Location
+}
+
+// Diagnostic is one problem found in a changelog. LineNumber and Column are
+// 1-based and zero for problems that concern the whole changelog or were
+// found by Validate. Path is left for the caller to fill in.
+type Diagnostic struct {
+	ReleaseVersion string
+	ReleaseDate    string
+	Reason         string
+	Line           string
+
+	Path       string
+	LineNumber int
+	Column     int
+	// Rule is one of the Rule constants. It is empty when the changelog
+	// could not be read at all.
+	Rule string
+	// Fix suggests how to resolve the problem.
+	Fix string
+}
+
+func (d Diagnostic) Error() string {
+	if d.LineNumber == 0 {
+		reason := d.Reason
+		if d.ReleaseVersion != "" {
+			reason = fmt.Sprintf("release %s: %s", d.ReleaseVersion, d.Reason)
+		}
+		if d.Path != "" {
+			return fmt.Sprintf("%s: %s", d.Path, reason)
+		}
+		return reason
+	}
+
+	message := ""
+	if d.ReleaseVersion == "" {
+		message = fmt.Sprintf("invalid changelog entry\nReason: %s\nLine: %s", d.Reason, d.Line)
+	} else {
+		message = fmt.Sprintf("invalid entry at release %s (%s)\nReason: %s\nLine: %s", d.ReleaseVersion, d.ReleaseDate, d.Reason, d.Line)
+	}
+
+	if d.Path != "" {
+		message += fmt.Sprintf("\nLocation: %s:%d:%d", d.Path, d.LineNumber, d.Column)
+	}
+
+	return message
+}
+
+// Parse reads a Markdown changelog. It keeps going after a problem and
+// returns every diagnostic in line order, with problems that concern the
+// whole changelog last. Entries with problems are left out of the result.
+func Parse(r io.Reader, opts Options) (Changelog, []Diagnostic) {
+	lines, err := readLines(r)
+	if err != nil {
+		return Changelog{}, []Diagnostic{{Reason: err.Error()}}
+	}
+
+	dialect, err := ResolveDialect(opts.Dialect, lines)
+	if err != nil {
+		return Changelog{}, []Diagnostic{{Reason: err.Error()}}
+	}
+
+	taxonomy := opts.taxonomy()
+	now := opts.now().In(opts.location())
+
+	log := Changelog{Releases: []Release{}}
+	diagnostics := []Diagnostic{}
+	headers := []releaseHeader{}
+	group := 0
+	currentRelease := -1
+	currentUpstream := -1
+	currentSectionTag := ""
+	skippingEntries := false
+	skippingSublevels := false
+
+	for i, line := range lines {
+		lineNumber := i + 1
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		leftTrimmed := strings.TrimLeft(line, " \t")
+		trimmed := strings.TrimSpace(line)
+
+		report := func(rule string, column int, reason, fix string) {
+			diagnostics = append(diagnostics, Diagnostic{
+				ReleaseVersion: releaseVersion(log.Releases, currentRelease),
+				ReleaseDate:    releaseDate(log.Releases, currentRelease),
+				Reason:         reason,
+				Line:           line,
+				LineNumber:     lineNumber,
+				Column:         column,
+				Rule:           rule,
+				Fix:            fix,
+			})
+		}
+
+		if trimmed == "" {
+			continue
+		}
+
+		if matches := dialect.ReleaseHeader.FindStringSubmatch(trimmed); matches != nil {
+			// Date problems belong to the new release, not the previous one.
+			date, err := CanonicalReleaseDate(matches[2])
+			dateValid := err == nil
+			if err != nil {
+				currentRelease = -1
+				report(RuleInvalidReleaseDate, strings.Index(line, matches[2])+1, err.Error(), "use a calendar date formatted as YYYY-MM-DD")
+				date = matches[2]
+			} else if err := validateReleaseDate(date, now); err != nil {
+				currentRelease = -1
+				report(RuleFutureReleaseDate, strings.Index(line, matches[2])+1, err.Error(), "use the date the release is published, not a future one")
+			}
+
+			log.Releases = append(log.Releases, Release{Version: matches[1], Date: date, Entries: []Entry{}})
+			headers = append(headers, releaseHeader{Line: line, LineNumber: lineNumber, Group: group, DateValid: dateValid})
+			currentRelease = len(log.Releases) - 1
+			currentUpstream = -1
+			currentSectionTag = ""
+			skippingEntries = false
+			skippingSublevels = false
+			continue
+		}
+
+		if dialect.SkippedHeader != nil && dialect.SkippedHeader.MatchString(trimmed) {
+			skippingEntries = true
+			continue
+		}
+
+		if dialect.StrictHeaderPrefix != "" && strings.HasPrefix(trimmed, dialect.StrictHeaderPrefix) {
+			fix := fmt.Sprintf("rewrite the header as %q", dialect.HeaderFormat)
+			if header, ok := dialect.FormatHeader(trimmed); ok {
+				fix = fmt.Sprintf("replace with %q", header)
+			}
+			report(RuleInvalidReleaseHeader, indent+1, fmt.Sprintf("release header must match %q", dialect.HeaderFormat), fix)
+			continue
+		}
+
+		if IsGroupHeading(trimmed) {
+			// A release header at the wrong level would otherwise open a new
+			// group and fold its entries into the previous release.
+			if header, ok := dialect.FormatHeader(trimmed); ok {
+				report(RuleInvalidReleaseHeader, indent+1, fmt.Sprintf("release header must match %q", dialect.HeaderFormat), fmt.Sprintf("replace with %q", header))
+				continue
+			}
+			group++
+		}
+
+		if strings.HasPrefix(trimmed, "#### Changes from ") {
+			matches := upstreamHeaderPattern.FindStringSubmatch(trimmed)
+			if matches == nil {
+				report(RuleInvalidUpstreamHeader, indent+1, `upstream header must match "#### Changes from <chart> X.Y.Z:"`, `use "#### Changes from opentelemetry-collector X.Y.Z:"`)
+				continue
+			}
+
+			if currentRelease < 0 {
+				report(RuleUpstreamBeforeRelease, indent+1, fmt.Sprintf("upstream header found before first release header at line %d", lineNumber), "move the section below its release header")
+				continue
+			}
+
+			release := &log.Releases[currentRelease]
+			release.Upstream = append(release.Upstream, UpstreamChangelog{
+				Chart:   matches[1],
+				Version: matches[2],
+				Entries: []Entry{},
+			})
+			currentUpstream = len(release.Upstream) - 1
+			continue
+		}
+
+		if tag, ok := dialect.sectionTag(trimmed); ok {
+			currentSectionTag = tag
+			continue
+		}
+
+		bulletText, ok := dialect.bulletText(leftTrimmed)
+		if !ok && !skippingEntries && strings.HasPrefix(leftTrimmed, "* ") && (indent == 0 || !skippingSublevels) {
+			// fmt rewrites these bullets, which would add them to the output
+			marker := dialect.BulletMarkers[0]
+			report(RuleMalformedEntry, indent+1, fmt.Sprintf("entry must start with %q", marker), fmt.Sprintf("replace %q with %q", "* ", marker))
+			if indent == 0 {
+				skippingSublevels = true
+			}
+			continue
+		}
+		if !ok || skippingEntries {
+			continue
+		}
+
+		if indent > 0 {
+			if skippingSublevels {
+				continue
+			}
+
+			if currentRelease < 0 || len(log.Releases[currentRelease].Entries) == 0 {
+				report(RuleOrphanSublevelEntry, indent+1, "sublevel entry must follow a top-level tagged entry", "remove the indentation or add a tagged parent entry")
+				continue
+			}
+
+			if bulletText == "" {
+				report(RuleEmptyEntryText, indent+1, "sublevel entry text must not be empty", "remove the empty bullet")
+				continue
+			}
+
+			appendSublevelText(&log.Releases[currentRelease], bulletText)
+			continue
+		}
+
+		skippingSublevels = false
+
+		if taxonomy.IsDropped(trimmed) {
+			skippingSublevels = true
+			continue
+		}
+
+		if currentRelease < 0 {
+			report(RuleEntryBeforeRelease, 1, fmt.Sprintf("entry found before first release header at line %d", lineNumber), "move the entry below a release header")
+			skippingSublevels = true
+			continue
+		}
+
+		var tags []string
+		text := bulletText
+		switch {
+		case dialect.SectionTags != nil:
+			if currentSectionTag == "" {
+				skippingSublevels = true
+				continue
+			}
+			tags = []string{currentSectionTag}
+		case dialect.DefaultTag != "" && !strings.HasPrefix(bulletText, "["):
+			tags = []string{dialect.DefaultTag}
+		default:
+			tags, text, ok = parseEntry(bulletText)
+			if !ok {
+				report(RuleMalformedEntry, 1, `entry must match "- [Tag] text..."`, `start the entry with a tag, for example "- [Fix] "`)
+				skippingSublevels = true
+				continue
+			}
+		}
+
+		tagsValid := true
+		for _, tag := range tags {
+			if err := taxonomy.ValidateTag(tag); err != nil {
+				report(RuleUnknownTag, tagColumn(line, tag), err.Error(), suggestTag(taxonomy, tag))
+				tagsValid = false
+			}
+		}
+
+		if text == "" {
+			report(RuleEmptyEntryText, 1, "entry text must not be empty", "describe the change after the tag")
+			tagsValid = false
+		}
+
+		if !tagsValid {
+			skippingSublevels = true
+			continue
+		}
+
+		entry := Entry{
+			Tag:      taxonomy.NormalizeTag(taxonomy.SelectPrimaryTag(tags)),
+			Text:     text,
+			Origin:   OriginChart,
+			Breaking: hasBreakingTag(taxonomy, tags),
+			Warning:  hasSecondaryTag(taxonomy, tags),
+		}
+
+		release := &log.Releases[currentRelease]
+		if currentUpstream >= 0 {
+			upstream := &release.Upstream[currentUpstream]
+			entry.Origin = upstream.Chart
+			upstream.Entries = append(upstream.Entries, entry)
+		} else if entry.Breaking {
+			headers[currentRelease].Breaking = true
+		}
+
+		release.Entries = append(release.Entries, entry)
+	}
+
+	if len(log.Releases) == 0 {
+		diagnostics = append(diagnostics, Diagnostic{
+			Reason: "no releases found",
+			Rule:   RuleNoReleases,
+			Fix:    fmt.Sprintf("add a release header such as %q", dialect.HeaderFormat),
+		})
+	}
+
+	// Sequence problems are found after the scan; keep the report in line
+	// order with file-wide problems last.
+	diagnostics = append(diagnostics, checkReleaseSequence(log.Releases, headers, opts)...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[j].LineNumber == 0 && diagnostics[i].LineNumber != 0 ||
+			diagnostics[i].LineNumber != 0 && diagnostics[i].LineNumber < diagnostics[j].LineNumber
+	})
+
+	return log, diagnostics
+}
+
+// appendSublevelText adds a nested bullet to the release's last entry, keeping
+// the copy in the matching upstream changelog in sync.
+func appendSublevelText(release *Release, text string) {
+	last := len(release.Entries) - 1
+	release.Entries[last].Text += "\n- " + text
+
+	if release.Entries[last].Origin == OriginChart || len(release.Upstream) == 0 {
+		return
+	}
+
+	upstream := &release.Upstream[len(release.Upstream)-1]
+	if n := len(upstream.Entries); n > 0 {
+		upstream.Entries[n-1].Text = release.Entries[last].Text
+	}
+}
+
+// readLines splits on LF, CRLF and lone CR line breaks alike, so entry text
+// does not depend on the line endings of the checkout.
+func readLines(r io.Reader) ([]string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading changelog: %w", err)
+	}
+
+	text := strings.TrimSuffix(normalizeNewlines(string(content)), "\n")
+	if text == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(text, "\n"), nil
+}
+
+func parseEntry(text string) ([]string, string, bool) {
+	rest := strings.TrimSpace(text)
+	tags := make([]string, 0, 1)
+
+	for strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end <= 1 {
+			return nil, "", false
+		}
+
+		tag := strings.TrimSpace(rest[1:end])
+		if tag == "" {
+			return nil, "", false
+		}
+
+		tags = append(tags, tag)
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	if len(tags) == 0 || rest == "" {
+		return nil, "", false
+	}
+
+	return tags, rest, true
+}
+
+// tagColumn returns the 1-based column of "[tag]" in line.
+func tagColumn(line, tag string) int {
+	if idx := strings.Index(line, "["+tag+"]"); idx >= 0 {
+		return idx + 1
+	}
+
+	return 1
+}
+
+func releaseVersion(releases []Release, index int) string {
+	if index < 0 || index >= len(releases) {
+		return ""
+	}
+
+	return releases[index].Version
+}
+
+func releaseDate(releases []Release, index int) string {
+	if index < 0 || index >= len(releases) {
+		return ""
+	}
+
+	return releases[index].Date
+}
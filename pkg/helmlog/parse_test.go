@@ -0,0 +1,155 @@
+package helmlog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const parseTestChangelog = `# Changelog
+
+### v0.0.2 / 2026-01-02
+- [:warning: Change][Feat] Enable batching by default
+  - Set batch.enabled to false to opt out
+
+#### Changes from opentelemetry-collector 0.101.0:
+- [Fix] Fix exporter retries
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+- [Chore] Bump chart dependency to opentelemetry-collector 0.100.0
+`
+
+func TestParse(t *testing.T) {
+	log, diagnostics := Parse(strings.NewReader(parseTestChangelog), Options{})
+	if len(diagnostics) != 0 {
+		t.Fatalf("Parse() diagnostics = %v", diagnostics)
+	}
+
+	want := Changelog{Releases: []Release{
+		{
+			Version: "v0.0.2",
+			Date:    "2026-01-02",
+			Entries: []Entry{
+				{Tag: "Feat", Text: "Enable batching by default\n- Set batch.enabled to false to opt out", Origin: OriginChart, Warning: true},
+				{Tag: "Fix", Text: "Fix exporter retries", Origin: "opentelemetry-collector"},
+			},
+			Upstream: []UpstreamChangelog{{
+				Chart:   "opentelemetry-collector",
+				Version: "0.101.0",
+				Entries: []Entry{{Tag: "Fix", Text: "Fix exporter retries", Origin: "opentelemetry-collector"}},
+			}},
+		},
+		{
+			Version: "v0.0.1",
+			Date:    "2026-01-01",
+			Entries: []Entry{{Tag: "Feat", Text: "Initial release", Origin: OriginChart}},
+		},
+	}}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("Parse() = %#v, want %#v", log, want)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	taxonomy, err := NewTaxonomy(TaxonomyConfig{Tags: map[string][]string{"Added": {"feat"}}})
+	if err != nil {
+		t.Fatalf("NewTaxonomy() error = %v", err)
+	}
+
+	now := func() time.Time { return time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local) }
+	log, diagnostics := Parse(strings.NewReader(parseTestChangelog), Options{Dialect: DialectHelm, Taxonomy: taxonomy, Now: now})
+
+	// The custom taxonomy has no secondary tags and drops no entries.
+	got := []string{}
+	for _, d := range diagnostics {
+		got = append(got, fmt.Sprintf("%d %s %s", d.LineNumber, d.Rule, d.ReleaseVersion))
+	}
+	want := []string{
+		"3 " + RuleFutureReleaseDate + " ",
+		"4 " + RuleUnknownTag + " v0.0.2",
+		"8 " + RuleUnknownTag + " v0.0.2",
+		"12 " + RuleUnknownTag + " v0.0.1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse() diagnostics = %#v, want %#v", got, want)
+	}
+	if entries := log.Releases[1].Entries; len(entries) != 1 || entries[0].Tag != "Added" {
+		t.Fatalf("v0.0.1 entries = %#v, want one [Added] entry", entries)
+	}
+
+	_, diagnostics = Parse(strings.NewReader(parseTestChangelog), Options{Dialect: "mkdocs"})
+	if len(diagnostics) != 1 || diagnostics[0].Rule != "" || !strings.Contains(diagnostics[0].Error(), `unknown changelog dialect "mkdocs"`) {
+		t.Fatalf("Parse() with unknown dialect = %#v", diagnostics)
+	}
+}
+
+func TestParseLocationSetsCalendarDay(t *testing.T) {
+	changelog := "### v0.0.1 / 2026-02-11\n- [Feat] Initial release\n"
+	now := func() time.Time { return time.Date(2026, 2, 10, 23, 30, 0, 0, time.UTC) }
+
+	_, diagnostics := Parse(strings.NewReader(changelog), Options{Now: now, Location: time.FixedZone("UTC+2", 2*60*60)})
+	if len(diagnostics) != 0 {
+		t.Fatalf("Parse() in UTC+2 diagnostics = %v, want none", diagnostics)
+	}
+
+	_, diagnostics = Parse(strings.NewReader(changelog), Options{Now: now, Location: time.UTC})
+	if len(diagnostics) != 1 || diagnostics[0].Rule != RuleFutureReleaseDate {
+		t.Fatalf("Parse() in UTC diagnostics = %v, want %s", diagnostics, RuleFutureReleaseDate)
+	}
+}
+
+func TestParseMisleveledReleaseHeader(t *testing.T) {
+	changelog := `# Changelog
+
+### v0.0.3 / 2026-01-03
+- [Feat] Third release
+
+## v0.0.2 / 2026-01-02
+- [Fix] Second release
+
+## Fluent-Bit
+
+### v0.0.1 / 2026-01-01
+- [Feat] Initial release
+`
+
+	log, diagnostics := Parse(strings.NewReader(changelog), Options{Dialect: DialectHelm})
+	if len(diagnostics) != 1 || diagnostics[0].Rule != RuleInvalidReleaseHeader || diagnostics[0].LineNumber != 6 {
+		t.Fatalf("Parse() diagnostics = %v, want %s at line 6", diagnostics, RuleInvalidReleaseHeader)
+	}
+	if want := `replace with "### v0.0.2 / 2026-01-02"`; diagnostics[0].Fix != want {
+		t.Fatalf("Fix = %q, want %q", diagnostics[0].Fix, want)
+	}
+	if len(log.Releases) != 2 {
+		t.Fatalf("Parse() releases = %#v, want v0.0.3 and v0.0.1", log.Releases)
+	}
+}
+
+func TestParseStarBulletInHelmDialect(t *testing.T) {
+	changelog := `### v0.0.2 / 2026-01-02
+* [Fix] Star bullet
+  - nested detail
+- [Feat] Dash bullet
+`
+
+	log, diagnostics := Parse(strings.NewReader(changelog), Options{Dialect: DialectHelm})
+	if len(diagnostics) != 1 || diagnostics[0].Rule != RuleMalformedEntry || diagnostics[0].LineNumber != 2 {
+		t.Fatalf("Parse() diagnostics = %v, want %s at line 2", diagnostics, RuleMalformedEntry)
+	}
+	if want := `replace "* " with "- "`; diagnostics[0].Fix != want {
+		t.Fatalf("Fix = %q, want %q", diagnostics[0].Fix, want)
+	}
+	if len(log.Releases) != 1 || len(log.Releases[0].Entries) != 1 {
+		t.Fatalf("Parse() releases = %#v, want only the dash entry", log.Releases)
+	}
+
+	_, diagnostics = Parse(strings.NewReader(changelog), Options{Dialect: DialectKeepAChangelog})
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Rule == RuleMalformedEntry {
+			t.Fatalf("Parse() in keep-a-changelog diagnostics = %v, want star bullets accepted", diagnostics)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package helmlog
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Index is the part of a Helm repository index.yaml that helmlog reads.
+type Index struct {
+	APIVersion string                  `json:"apiVersion" yaml:"apiVersion"`
+	Entries    map[string][]IndexEntry `json:"entries" yaml:"entries"`
+}
+
+// IndexEntry is one published version of a chart in a repository index.
+type IndexEntry struct {
+	Name       string   `json:"name" yaml:"name"`
+	Version    string   `json:"version" yaml:"version"`
+	AppVersion string   `json:"appVersion,omitempty" yaml:"appVersion,omitempty"`
+	URLs       []string `json:"urls,omitempty" yaml:"urls,omitempty"`
+	Digest     string   `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// ParseIndex decodes the content of a Helm repository index.yaml file.
+func ParseIndex(content []byte) (Index, error) {
+	var index Index
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return Index{}, fmt.Errorf("invalid index.yaml: %w", err)
+	}
+
+	return index, nil
+}
+
+// Lookup returns the entry published for chart name at version. A leading
+// "v" is ignored on both sides, as Helm does when it resolves versions.
+func (i Index) Lookup(name, version string) (IndexEntry, bool) {
+	version = strings.TrimPrefix(version, "v")
+	for _, entry := range i.Entries[name] {
+		if strings.TrimPrefix(entry.Version, "v") == version {
+			return entry, true
+		}
+	}
+
+	return IndexEntry{}, false
+}
+
+// ReadChartArchive reads the Chart.yaml of a packaged chart (.tgz). Only the
+// top-level chart is returned; subcharts under charts/ are ignored.
+func ReadChartArchive(r io.Reader) (Chart, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Chart{}, fmt.Errorf("invalid chart archive: %w", err)
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return Chart{}, errors.New("invalid chart archive: no Chart.yaml")
+		}
+		if err != nil {
+			return Chart{}, fmt.Errorf("invalid chart archive: %w", err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if header.Typeflag != tar.TypeReg || path.Base(name) != "Chart.yaml" || path.Dir(path.Dir(name)) != "." {
+			continue
+		}
+
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return Chart{}, fmt.Errorf("invalid chart archive: %w", err)
+		}
+
+		return ParseChart(content)
+	}
+}
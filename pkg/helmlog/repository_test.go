@@ -0,0 +1,75 @@
+package helmlog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func TestIndexLookup(t *testing.T) {
+	index, err := ParseIndex([]byte(`apiVersion: v1
+entries:
+  opentelemetry-collector:
+    - name: opentelemetry-collector
+      version: 0.135.4
+      appVersion: 0.137.0
+      urls: [opentelemetry-collector-0.135.4.tgz]
+    - name: opentelemetry-collector
+      version: v0.135.3
+      appVersion: 0.136.0
+`))
+	if err != nil {
+		t.Fatalf("ParseIndex() error = %v", err)
+	}
+
+	entry, ok := index.Lookup("opentelemetry-collector", "v0.135.4")
+	if !ok || entry.AppVersion != "0.137.0" {
+		t.Fatalf("Lookup(0.135.4) = %#v, %v", entry, ok)
+	}
+	entry, ok = index.Lookup("opentelemetry-collector", "0.135.3")
+	if !ok || entry.AppVersion != "0.136.0" {
+		t.Fatalf("Lookup(0.135.3) = %#v, %v", entry, ok)
+	}
+	if _, ok := index.Lookup("opentelemetry-collector", "0.1.0"); ok {
+		t.Fatalf("Lookup(0.1.0) found, want missing")
+	}
+	if _, ok := index.Lookup("coralogix-operator", "0.135.4"); ok {
+		t.Fatalf("Lookup(coralogix-operator) found, want missing")
+	}
+}
+
+func TestReadChartArchiveSkipsSubcharts(t *testing.T) {
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(gz)
+	for _, file := range []struct{ name, content string }{
+		{"collector/charts/common/Chart.yaml", "name: common\nversion: 1.0.0\nappVersion: 9.9.9\n"},
+		{"collector/Chart.yaml", "name: collector\nversion: 0.135.4\nappVersion: 0.137.0\n"},
+	} {
+		if err := archive.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("WriteHeader() error = %v", err)
+		}
+		if _, err := archive.Write([]byte(file.content)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("tar Close() error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close() error = %v", err)
+	}
+
+	chart, err := ReadChartArchive(&buffer)
+	if err != nil {
+		t.Fatalf("ReadChartArchive() error = %v", err)
+	}
+	if chart.Name != "collector" || chart.AppVersion != "0.137.0" {
+		t.Fatalf("chart = %#v", chart)
+	}
+
+	if _, err := ReadChartArchive(bytes.NewReader([]byte("not a chart"))); err == nil {
+		t.Fatalf("ReadChartArchive() error = nil for invalid archive")
+	}
+}
@@ -0,0 +1,185 @@
+package helmlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rule IDs of the diagnostics reported by Parse and Validate. They are part
+// of the helmlog validate output and must stay stable.
+const (
+	RuleInvalidReleaseDate    = "invalid-release-date"
+	RuleFutureReleaseDate     = "future-release-date"
+	RuleInvalidReleaseHeader  = "invalid-release-header"
+	RuleInvalidUpstreamHeader = "invalid-upstream-header"
+	RuleUpstreamBeforeRelease = "upstream-before-release"
+	RuleOrphanSublevelEntry   = "orphan-sublevel-entry"
+	RuleEntryBeforeRelease    = "entry-before-release"
+	RuleMalformedEntry        = "malformed-entry"
+	RuleUnknownTag            = "unknown-tag"
+	RuleEmptyEntryText        = "empty-entry-text"
+	RuleNoReleases            = "no-releases"
+	RuleInvalidVersion        = "invalid-version"
+	RuleDuplicateRelease      = "duplicate-release"
+	RuleReleaseOrder          = "release-order"
+	RuleReleaseDateOrder      = "release-date-order"
+	RuleBreakingChangeBump    = "breaking-change-bump"
+)
+
+var ruleDescriptions = map[string]string{
+	RuleInvalidReleaseDate:    "Release dates must be valid calendar dates.",
+	RuleFutureReleaseDate:     "Release dates must not be in the future.",
+	RuleInvalidReleaseHeader:  "Release headers must follow the changelog dialect.",
+	RuleInvalidUpstreamHeader: "Upstream sections must name the chart and its version.",
+	RuleUpstreamBeforeRelease: "Upstream sections must belong to a release.",
+	RuleOrphanSublevelEntry:   "Nested bullets must follow a top-level entry.",
+	RuleEntryBeforeRelease:    "Entries must belong to a release.",
+	RuleMalformedEntry:        "Entries must start with a tag.",
+	RuleUnknownTag:            "Entry tags must be in the allowed tag list.",
+	RuleEmptyEntryText:        "Entries must have text.",
+	RuleNoReleases:            "A changelog must contain at least one release.",
+	RuleInvalidVersion:        "Release versions must be valid SemVer.",
+	RuleDuplicateRelease:      "Each release version must appear once.",
+	RuleReleaseOrder:          "Release versions must strictly decrease down the file.",
+	RuleReleaseDateOrder:      "Release dates must not increase as versions decrease.",
+	RuleBreakingChangeBump:    "Releases with breaking changes must bump the minor or major version.",
+}
+
+// RuleIDs lists every rule ID, sorted.
+func RuleIDs() []string {
+	ids := make([]string, 0, len(ruleDescriptions))
+	for id := range ruleDescriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// RuleDescription returns a one-sentence description of a rule.
+func RuleDescription(id string) (string, bool) {
+	description, ok := ruleDescriptions[id]
+	return description, ok
+}
+
+// releaseHeader records where a release was declared so sequence rules can
+// point at it. Group counts the "# " and "## " headings seen before it;
+// releases under different headings, such as "## Fluentd" and
+// "## Fluent-Bit", are versioned independently.
+type releaseHeader struct {
+	Line       string
+	LineNumber int
+	Group      int
+	DateValid  bool
+	Breaking   bool
+}
+
+// location names the header's line for messages about other releases.
+// Releases checked by Validate have no line.
+func (h releaseHeader) location() string {
+	if h.LineNumber == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" at line %d", h.LineNumber)
+}
+
+// checkReleaseSequence applies the rules that compare a release with the ones
+// around it. headers[i] describes releases[i], both in file order.
+func checkReleaseSequence(releases []Release, headers []releaseHeader, opts Options) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	versions := make([]Semver, len(headers))
+	valid := make([]bool, len(headers))
+
+	report := func(i int, rule, reason, fix string) {
+		diagnostics = append(diagnostics, Diagnostic{
+			ReleaseVersion: releases[i].Version,
+			ReleaseDate:    releases[i].Date,
+			Reason:         reason,
+			Line:           headers[i].Line,
+			LineNumber:     headers[i].LineNumber,
+			Column:         strings.Index(headers[i].Line, releases[i].Version) + 1,
+			Rule:           rule,
+			Fix:            fix,
+		})
+	}
+
+	previous := -1
+	for i, header := range headers {
+		if previous >= 0 && headers[previous].Group != header.Group {
+			previous = -1
+		}
+
+		version, ok := ParseVersion(releases[i].Version)
+		if !ok {
+			report(i, RuleInvalidVersion, fmt.Sprintf("invalid release version %q", releases[i].Version), "use a SemVer version such as v1.2.3, v1.2.3-rc.1 or v1.2.3+build.5")
+			continue
+		}
+		versions[i] = version
+		valid[i] = true
+
+		if first := firstEqualVersion(headers, versions, valid, i); first >= 0 {
+			report(i, RuleDuplicateRelease, fmt.Sprintf("release %s already appears%s", releases[i].Version, headers[first].location()), "merge the entries into one release or correct the version")
+			continue
+		}
+
+		if previous >= 0 {
+			switch {
+			case version.Compare(versions[previous]) > 0:
+				report(i, RuleReleaseOrder, fmt.Sprintf("release %s must be lower than %s%s", releases[i].Version, releases[previous].Version, headers[previous].location()), "list releases newest first, or run helmlog fmt --write")
+			case header.DateValid && headers[previous].DateValid && releases[i].Date > releases[previous].Date:
+				report(i, RuleReleaseDateOrder, fmt.Sprintf("release %s dated %s is newer than %s dated %s%s", releases[i].Version, releases[i].Date, releases[previous].Version, releases[previous].Date, headers[previous].location()), "correct the release date or version")
+			}
+		}
+
+		previous = i
+	}
+
+	for i, header := range headers {
+		if !header.Breaking || !valid[i] {
+			continue
+		}
+
+		older := olderRelease(headers, valid, i)
+		if older < 0 {
+			continue
+		}
+
+		// Every 0.0.z release may start a new initial-development line
+		from := versions[older]
+		if opts.InitialDevelopment && from.Major == 0 && from.Minor == 0 {
+			continue
+		}
+
+		if versions[i].Major == from.Major && versions[i].Minor == from.Minor {
+			report(i, RuleBreakingChangeBump, fmt.Sprintf("release %s has breaking changes but only bumps the patch version of %s", releases[i].Version, releases[older].Version), fmt.Sprintf("release as v%d.%d.0 or later", from.Major, from.Minor+1))
+		}
+	}
+
+	return diagnostics
+}
+
+// firstEqualVersion returns the earliest release in the same group as i with
+// the same version precedence, or -1.
+func firstEqualVersion(headers []releaseHeader, versions []Semver, valid []bool, i int) int {
+	for j := 0; j < i; j++ {
+		if valid[j] && headers[j].Group == headers[i].Group && versions[j].Compare(versions[i]) == 0 {
+			return j
+		}
+	}
+
+	return -1
+}
+
+// olderRelease returns the release listed right below i in the same group, or
+// -1.
+func olderRelease(headers []releaseHeader, valid []bool, i int) int {
+	for j := i + 1; j < len(headers) && headers[j].Group == headers[i].Group; j++ {
+		if valid[j] {
+			return j
+		}
+	}
+
+	return -1
+}
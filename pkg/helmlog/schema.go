@@ -0,0 +1,107 @@
+package helmlog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//go:generate go run ../../cmd/helmlog schema --output changelog.schema.json
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaDescriptions documents the types of the artifact in the schema.
+var schemaDescriptions = map[string]string{
+	"Changelog":         "A changelog as written by helmlog generate, newest release first.",
+	"Release":           "A single versioned changelog section. Entries lists every entry of the release, including the ones inherited from upstream charts.",
+	"UpstreamChangelog": "The entries a release inherits from a dependency chart.",
+	"Entry":             "A normalized changelog item. Origin is \"chart\" or the name of the upstream chart it was inherited from.",
+}
+
+// schemaPatterns constrains string properties, keyed by "Type.property".
+var schemaPatterns = map[string]string{
+	"Release.version":           `^v?` + SemverExpr + `$`,
+	"Release.date":              `^\d{4}-\d{2}-\d{2}$`,
+	"UpstreamChangelog.version": `^v?` + SemverExpr + `$`,
+}
+
+// Schema returns the JSON Schema of the artifact written by Marshal. It is
+// derived from the json tags of Changelog: fields without omitempty are
+// required and no other properties are allowed.
+func Schema() ([]byte, error) {
+	defs := map[string]any{}
+	root := structSchema(reflect.TypeOf(Changelog{}), defs)
+	root["$schema"] = schemaDraft
+	root["title"] = "Changelog"
+	root["$defs"] = defs
+
+	output, err := encodeCanonical(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	return output, nil
+}
+
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			defs[t.Name()] = nil
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	default:
+		panic(fmt.Sprintf("helmlog: no schema for %s", t))
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := typeSchema(field.Type, defs)
+		if pattern, ok := schemaPatterns[t.Name()+"."+name]; ok {
+			property["pattern"] = pattern
+		}
+		properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if description, ok := schemaDescriptions[t.Name()]; ok {
+		schema["description"] = description
+	}
+
+	return schema
+}
@@ -0,0 +1,88 @@
+package helmlog
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSchemaMatchesCommittedFile(t *testing.T) {
+	schema, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+
+	committed, err := os.ReadFile("changelog.schema.json")
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+
+	if string(committed) != string(schema) {
+		t.Fatalf("changelog.schema.json is out of date; run go generate ./pkg/helmlog")
+	}
+}
+
+func TestSchemaDescribesMarshalOutput(t *testing.T) {
+	log, diagnostics := Parse(strings.NewReader(parseTestChangelog), Options{})
+	if len(diagnostics) != 0 {
+		t.Fatalf("Parse() diagnostics = %v", diagnostics)
+	}
+	log.ParseMetadata()
+	log.Releases[0].Entries[0].Authors = []string{"jane-doe"}
+	log.Releases[0].Entries[0].PullRequests = []int{12}
+
+	output, err := Marshal(log)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	content, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("json.Unmarshal(schema) error = %v", err)
+	}
+
+	var artifact struct {
+		Releases []map[string]json.RawMessage `json:"releases"`
+	}
+	if err := json.Unmarshal(output, &artifact); err != nil {
+		t.Fatalf("json.Unmarshal(artifact) error = %v", err)
+	}
+
+	// Every property written by Marshal is declared, and every required
+	// property is written.
+	check := func(def string, object map[string]json.RawMessage) {
+		t.Helper()
+		for name := range object {
+			if _, ok := schema.Defs[def].Properties[name]; !ok {
+				t.Fatalf("%s property %q is not in the schema", def, name)
+			}
+		}
+		for _, name := range schema.Defs[def].Required {
+			if _, ok := object[name]; !ok {
+				t.Fatalf("%s is missing required property %q", def, name)
+			}
+		}
+	}
+	for _, release := range artifact.Releases {
+		check("Release", release)
+
+		var entries []map[string]json.RawMessage
+		if err := json.Unmarshal(release["entries"], &entries); err != nil {
+			t.Fatalf("entries are not an array: %s", release["entries"])
+		}
+		for _, entry := range entries {
+			check("Entry", entry)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package helmlog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TaxonomyConfig lists the tags a changelog may use and how they combine.
+type TaxonomyConfig struct {
+	// Tags maps each canonical tag name to the spellings accepted for it.
+	Tags map[string][]string `json:"tags,omitempty" yaml:"tags"`
+	// SecondaryTags qualify another tag, like "[:warning: Change][Feat]", and
+	// are only used as the primary tag when nothing else is present.
+	SecondaryTags []string `json:"secondary_tags,omitempty" yaml:"secondary_tags"`
+	// Severity orders canonical tags from most to least important. When set,
+	// the most severe tag of an entry becomes its primary tag; otherwise the
+	// last non-secondary tag wins.
+	Severity []string `json:"severity,omitempty" yaml:"severity"`
+	// DroppedEntries are regular expressions for entries left out of the
+	// artifact.
+	DroppedEntries []string `json:"dropped_entries,omitempty" yaml:"dropped_entries"`
+}
+
+// DefaultTaxonomyConfig returns the tags used when no configuration is given.
+func DefaultTaxonomyConfig() TaxonomyConfig {
+	return TaxonomyConfig{
+		Tags: map[string][]string{
+			"Feat":     {"feat", "feature"},
+			"Fix":      {"fix", "bug"},
+			"Change":   {"change", ":warning: change"},
+			"Breaking": {"breaking", ":warning: breaking change"},
+			"Chore":    {"chore"},
+			"Revert":   {"revert"},
+			"Update":   {"update"},
+			"Docs":     {"docs"},
+		},
+		SecondaryTags:  []string{":warning: change", ":warning: breaking change"},
+		DroppedEntries: []string{`Bump chart dependency to opentelemetry-collector`},
+	}
+}
+
+// Taxonomy is the resolved tag configuration used while parsing.
+type Taxonomy struct {
+	canonical map[string]string
+	secondary map[string]bool
+	severity  map[string]int
+	dropped   []*regexp.Regexp
+}
+
+var defaultTaxonomy = mustNewTaxonomy(DefaultTaxonomyConfig())
+
+func mustNewTaxonomy(cfg TaxonomyConfig) *Taxonomy {
+	taxonomy, err := NewTaxonomy(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return taxonomy
+}
+
+// NewTaxonomy resolves cfg. Tag names and spellings are matched without
+// regard to case.
+func NewTaxonomy(cfg TaxonomyConfig) (*Taxonomy, error) {
+	taxonomy := &Taxonomy{
+		canonical: map[string]string{},
+		secondary: map[string]bool{},
+		severity:  map[string]int{},
+	}
+
+	for name, aliases := range cfg.Tags {
+		taxonomy.canonical[strings.ToLower(name)] = name
+		for _, alias := range aliases {
+			taxonomy.canonical[strings.ToLower(strings.TrimSpace(alias))] = name
+		}
+	}
+
+	for _, tag := range cfg.SecondaryTags {
+		taxonomy.secondary[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	for i, tag := range cfg.Severity {
+		taxonomy.severity[strings.ToLower(strings.TrimSpace(tag))] = i
+	}
+
+	for _, expr := range cfg.DroppedEntries {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dropped entry pattern %q: %w", expr, err)
+		}
+		taxonomy.dropped = append(taxonomy.dropped, pattern)
+	}
+
+	return taxonomy, nil
+}
+
+// ValidateTag returns an error for a tag that is not an accepted spelling.
+func (t *Taxonomy) ValidateTag(tag string) error {
+	if _, ok := t.canonical[strings.ToLower(strings.TrimSpace(tag))]; !ok {
+		return fmt.Errorf("unknown tag %q", "["+tag+"]")
+	}
+
+	return nil
+}
+
+// IsAllowed reports whether tag is an accepted spelling.
+func (t *Taxonomy) IsAllowed(tag string) bool {
+	_, ok := t.canonical[strings.ToLower(strings.TrimSpace(tag))]
+	return ok
+}
+
+// NormalizeTag returns the canonical name of tag, or tag itself when it is
+// unknown.
+func (t *Taxonomy) NormalizeTag(tag string) string {
+	if name, ok := t.canonical[strings.ToLower(strings.TrimSpace(tag))]; ok {
+		return name
+	}
+
+	return tag
+}
+
+// IsSecondary reports whether tag only qualifies another tag.
+func (t *Taxonomy) IsSecondary(tag string) bool {
+	return t.secondary[strings.ToLower(strings.TrimSpace(tag))]
+}
+
+// SelectPrimaryTag picks the tag an entry with several tags is listed under.
+func (t *Taxonomy) SelectPrimaryTag(tags []string) string {
+	if len(t.severity) > 0 {
+		best := ""
+		bestRank := len(t.severity) + 1
+		for _, tag := range tags {
+			if t.IsSecondary(tag) {
+				continue
+			}
+
+			rank, ok := t.severity[strings.ToLower(t.NormalizeTag(tag))]
+			if !ok {
+				rank = len(t.severity)
+			}
+			if rank <= bestRank {
+				best = tag
+				bestRank = rank
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+
+	for i := len(tags) - 1; i >= 0; i-- {
+		if !t.IsSecondary(tags[i]) {
+			return tags[i]
+		}
+	}
+
+	return tags[len(tags)-1]
+}
+
+// IsDropped reports whether an entry line is left out of the artifact.
+func (t *Taxonomy) IsDropped(line string) bool {
+	for _, pattern := range t.dropped {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowedTags lists every accepted spelling, sorted.
+func (t *Taxonomy) AllowedTags() []string {
+	tags := make([]string, 0, len(t.canonical))
+	for tag := range t.canonical {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	return tags
+}
+
+// hasBreakingTag reports whether any of tags is [Breaking].
+func hasBreakingTag(taxonomy *Taxonomy, tags []string) bool {
+	for _, tag := range tags {
+		if taxonomy.NormalizeTag(tag) == "Breaking" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasSecondaryTag reports whether any of tags is a qualifier such as
+// [:warning: Change].
+func hasSecondaryTag(taxonomy *Taxonomy, tags []string) bool {
+	for _, tag := range tags {
+		if taxonomy.IsSecondary(tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suggestTag proposes the closest allowed tag for a misspelled one.
+func suggestTag(taxonomy *Taxonomy, tag string) string {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	allowedTags := taxonomy.AllowedTags()
+
+	best := ""
+	bestDistance := 3
+	for _, allowed := range allowedTags {
+		if d := levenshtein(normalized, allowed); d < bestDistance {
+			best = allowed
+			bestDistance = d
+		}
+	}
+
+	if best != "" {
+		return fmt.Sprintf("replace with %q", "["+taxonomy.NormalizeTag(best)+"]")
+	}
+
+	return "use one of the allowed tags: " + strings.Join(allowedTags, ", ")
+}
+
+func levenshtein(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
@@ -0,0 +1,15 @@
+package helmlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestTag(t *testing.T) {
+	if got, want := suggestTag(defaultTaxonomy, "DOC"), `replace with "[Docs]"`; got != want {
+		t.Fatalf("suggestTag(DOC) = %q, want %q", got, want)
+	}
+	if got := suggestTag(defaultTaxonomy, "Improvement"); !strings.HasPrefix(got, "use one of the allowed tags") {
+		t.Fatalf("suggestTag(Improvement) = %q, want allowed tag list", got)
+	}
+}
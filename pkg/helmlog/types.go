@@ -1,14 +1,31 @@
 package helmlog
 
+// Entry origins.
+const (
+	// OriginChart marks entries written for the chart itself.
+	OriginChart = "chart"
+)
+
 // Changelog is the normalized changelog artifact structure.
 type Changelog struct {
 	Releases []Release `json:"releases" yaml:"releases"`
 }
 
-// Release is a single versioned changelog section.
+// Release is a single versioned changelog section. Entries lists every entry
+// of the release, including inherited ones; Upstream groups the inherited
+// entries by the upstream release they came from.
 type Release struct {
+	Version  string              `json:"version" yaml:"version"`
+	Date     string              `json:"date" yaml:"date"`
+	Entries  []Entry             `json:"entries" yaml:"entries"`
+	Upstream []UpstreamChangelog `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+}
+
+// UpstreamChangelog holds the entries a release inherits from a dependency
+// chart, as listed under "#### Changes from <chart> <version>:".
+type UpstreamChangelog struct {
+	Chart   string  `json:"chart" yaml:"chart"`
 	Version string  `json:"version" yaml:"version"`
-	Date    string  `json:"date" yaml:"date"`
 	Entries []Entry `json:"entries" yaml:"entries"`
 }
 
@@ -16,4 +33,19 @@ type Release struct {
 type Entry struct {
 	Tag  string `json:"tag" yaml:"tag"`
 	Text string `json:"text" yaml:"text"`
+	// Origin is OriginChart or the name of the upstream chart the entry was
+	// inherited from.
+	Origin string `json:"origin,omitempty" yaml:"origin,omitempty"`
+	// Breaking and Warning keep what the other tags of a multi-tag entry
+	// said once Tag was picked: Breaking for a [Breaking] tag, Warning for a
+	// qualifier such as [:warning: Change].
+	Breaking bool `json:"breaking,omitempty" yaml:"breaking,omitempty"`
+	Warning  bool `json:"warning,omitempty" yaml:"warning,omitempty"`
+
+	// Component, PullRequests and Authors are only set by ParseMetadata:
+	// the leading "spanMetrics: " scope, the trailing "(#1234)" references
+	// and the "@author" mentions of Text.
+	Component    string   `json:"component,omitempty" yaml:"component,omitempty"`
+	PullRequests []int    `json:"pull_requests,omitempty" yaml:"pull_requests,omitempty"`
+	Authors      []string `json:"authors,omitempty" yaml:"authors,omitempty"`
 }
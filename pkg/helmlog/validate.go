@@ -0,0 +1,59 @@
+package helmlog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks a Changelog that did not come from Parse, such as one
+// decoded from a generated artifact, against the rules Parse applies to
+// Markdown. The diagnostics carry no line numbers. Group headings are not
+// part of the model, so every release is checked as one sequence.
+func Validate(c Changelog, opts Options) []Diagnostic {
+	taxonomy := opts.taxonomy()
+	now := opts.now().In(opts.location())
+
+	diagnostics := []Diagnostic{}
+	headers := make([]releaseHeader, len(c.Releases))
+	for i, release := range c.Releases {
+		report := func(rule, reason, fix string) {
+			diagnostics = append(diagnostics, Diagnostic{
+				ReleaseVersion: release.Version,
+				ReleaseDate:    release.Date,
+				Reason:         reason,
+				Rule:           rule,
+				Fix:            fix,
+			})
+		}
+
+		if date, err := CanonicalReleaseDate(release.Date); err != nil || date != release.Date {
+			report(RuleInvalidReleaseDate, fmt.Sprintf("invalid release date %q", release.Date), "use a calendar date formatted as YYYY-MM-DD")
+		} else if err := validateReleaseDate(date, now); err != nil {
+			report(RuleFutureReleaseDate, err.Error(), "use the date the release is published, not a future one")
+		} else {
+			headers[i].DateValid = true
+		}
+
+		for _, entry := range release.Entries {
+			if err := taxonomy.ValidateTag(entry.Tag); err != nil {
+				report(RuleUnknownTag, err.Error(), suggestTag(taxonomy, entry.Tag))
+			}
+			if strings.TrimSpace(entry.Text) == "" {
+				report(RuleEmptyEntryText, "entry text must not be empty", "describe the change after the tag")
+			}
+			if entry.Breaking && (entry.Origin == "" || entry.Origin == OriginChart) {
+				headers[i].Breaking = true
+			}
+		}
+	}
+
+	if len(c.Releases) == 0 {
+		diagnostics = append(diagnostics, Diagnostic{
+			Reason: "no releases found",
+			Rule:   RuleNoReleases,
+			Fix:    "add at least one release",
+		})
+	}
+
+	return append(diagnostics, checkReleaseSequence(c.Releases, headers, opts)...)
+}
@@ -0,0 +1,75 @@
+package helmlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	log := Changelog{Releases: []Release{
+		{Version: "v0.2.0", Date: "2026-03-01", Entries: []Entry{{Tag: "Feat", Text: "Add presets", Origin: OriginChart}}},
+		{Version: "v0.2.0", Date: "2026-02-01", Entries: []Entry{{Tag: "Feature", Text: "Published twice"}}},
+		{Version: "v0.1.9", Date: "2026-2-1", Entries: []Entry{{Tag: "Feture", Text: " "}}},
+		{Version: "v0.1.8", Date: "2026-01-01", Entries: []Entry{{Tag: "Breaking", Text: "Drop the logs pipeline", Breaking: true}}},
+		{Version: "v0.1.7", Date: "2025-12-01", Entries: []Entry{}},
+		{Version: "0.1", Date: "2027-01-01", Entries: []Entry{}},
+	}}
+
+	now := func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local) }
+	diagnostics := Validate(log, Options{Now: now})
+
+	got := []string{}
+	for _, d := range diagnostics {
+		got = append(got, d.Rule+" "+d.ReleaseVersion)
+	}
+	want := []string{
+		RuleInvalidReleaseDate + " v0.1.9",
+		RuleUnknownTag + " v0.1.9",
+		RuleEmptyEntryText + " v0.1.9",
+		RuleFutureReleaseDate + " 0.1",
+		RuleDuplicateRelease + " v0.2.0",
+		RuleInvalidVersion + " 0.1",
+		RuleBreakingChangeBump + " v0.1.8",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Validate() = %#v, want %#v", got, want)
+	}
+
+	if got := diagnostics[4].Error(); got != "release v0.2.0: release v0.2.0 already appears" {
+		t.Fatalf("duplicate release message = %q", got)
+	}
+	if !strings.Contains(diagnostics[1].Fix, `"[Feat]"`) {
+		t.Fatalf("unknown tag fix = %q", diagnostics[1].Fix)
+	}
+
+	if diagnostics := Validate(Changelog{}, Options{}); len(diagnostics) != 1 || diagnostics[0].Rule != RuleNoReleases {
+		t.Fatalf("Validate(empty) = %#v", diagnostics)
+	}
+}
+
+func TestValidateAcceptsParsedChangelog(t *testing.T) {
+	log, diagnostics := Parse(strings.NewReader(parseTestChangelog), Options{})
+	if len(diagnostics) != 0 {
+		t.Fatalf("Parse() diagnostics = %v", diagnostics)
+	}
+
+	if diagnostics := Validate(log, Options{}); len(diagnostics) != 0 {
+		t.Fatalf("Validate() = %v, want none", diagnostics)
+	}
+}
+
+func TestValidateLocationSetsCalendarDay(t *testing.T) {
+	log := Changelog{Releases: []Release{{Version: "v0.0.1", Date: "2026-02-11", Entries: []Entry{{Tag: "Feat", Text: "Initial release", Origin: OriginChart}}}}}
+	now := func() time.Time { return time.Date(2026, 2, 10, 23, 30, 0, 0, time.UTC) }
+
+	if diagnostics := Validate(log, Options{Now: now, Location: time.FixedZone("UTC+2", 2*60*60)}); len(diagnostics) != 0 {
+		t.Fatalf("Validate() in UTC+2 = %v, want none", diagnostics)
+	}
+
+	diagnostics := Validate(log, Options{Now: now, Location: time.UTC})
+	if len(diagnostics) != 1 || diagnostics[0].Rule != RuleFutureReleaseDate {
+		t.Fatalf("Validate() in UTC = %v, want %s", diagnostics, RuleFutureReleaseDate)
+	}
+}
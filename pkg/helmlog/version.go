@@ -0,0 +1,141 @@
+package helmlog
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// SemverExpr is a regular expression for a SemVer 2.0 version without the
+// optional "v" prefix.
+const SemverExpr = `\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`
+
+// Semver is a parsed release version. Build metadata is kept for display but
+// ignored when comparing, as the SemVer specification requires.
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      string
+}
+
+// ParseVersion parses "vX.Y.Z[-pre][+build]". The boolean is false when the
+// version is not valid SemVer.
+func ParseVersion(version string) (Semver, bool) {
+	rest := strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	var out Semver
+	if core, build, ok := strings.Cut(rest, "+"); ok {
+		if !validIdentifiers(build) {
+			return Semver{}, false
+		}
+		rest = core
+		out.Build = build
+	}
+
+	if core, pre, ok := strings.Cut(rest, "-"); ok {
+		if !validIdentifiers(pre) {
+			return Semver{}, false
+		}
+		rest = core
+		out.Prerelease = strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Semver{}, false
+	}
+
+	numbers := [3]*int{&out.Major, &out.Minor, &out.Patch}
+	for i, part := range parts {
+		if !isNumeric(part) {
+			return Semver{}, false
+		}
+
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return Semver{}, false
+		}
+		*numbers[i] = v
+	}
+
+	return out, true
+}
+
+func validIdentifiers(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, identifier := range strings.Split(value, ".") {
+		if identifier == "" {
+			return false
+		}
+		for _, r := range identifier {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// CompareVersions orders two release versions by SemVer precedence: major,
+// minor and patch, then a release above any of its pre-releases. Versions
+// that do not parse compare as 0.0.0.
+func CompareVersions(a, b string) int {
+	va, _ := ParseVersion(a)
+	vb, _ := ParseVersion(b)
+
+	return va.Compare(vb)
+}
+
+// Compare returns -1, 0 or 1 when v has lower, equal or higher precedence
+// than other.
+func (v Semver) Compare(other Semver) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c := cmp.Compare(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := comparePrereleaseIdentifiers(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(v.Prerelease), len(other.Prerelease))
+}
+
+// comparePrereleaseIdentifiers compares numeric identifiers numerically and
+// ranks them below alphanumeric ones, which compare in ASCII order.
+func comparePrereleaseIdentifiers(a, b string) int {
+	switch {
+	case isNumeric(a) && isNumeric(b):
+		na, _ := strconv.Atoi(a)
+		nb, _ := strconv.Atoi(b)
+		return cmp.Compare(na, nb)
+	case isNumeric(a):
+		return -1
+	case isNumeric(b):
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isNumeric(value string) bool {
+	return value != "" && strings.TrimLeft(value, "0123456789") == ""
+}
@@ -0,0 +1,67 @@
+package helmlog
+
+import "testing"
+
+func TestParseVersionSemVer(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  Semver
+		valid bool
+	}{
+		{in: "v1.2.3", want: Semver{Major: 1, Minor: 2, Patch: 3}, valid: true},
+		{in: "0.0.18", want: Semver{Patch: 18}, valid: true},
+		{in: "v1.0.0-rc.1", want: Semver{Major: 1, Prerelease: []string{"rc", "1"}}, valid: true},
+		{in: "v1.0.0-rc.1+build.5", want: Semver{Major: 1, Prerelease: []string{"rc", "1"}, Build: "build.5"}, valid: true},
+		{in: "v1.0.0+20260101", want: Semver{Major: 1, Build: "20260101"}, valid: true},
+		{in: "v1.2", valid: false},
+		{in: "v1.2.x", valid: false},
+		{in: "v1.2.3-", valid: false},
+		{in: "v1.2.3-rc..1", valid: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.in)
+		if ok != tt.valid {
+			t.Fatalf("ParseVersion(%q) valid = %v, want %v", tt.in, ok, tt.valid)
+		}
+		if !ok {
+			continue
+		}
+		if got.Major != tt.want.Major || got.Minor != tt.want.Minor || got.Patch != tt.want.Patch || got.Build != tt.want.Build || len(got.Prerelease) != len(tt.want.Prerelease) {
+			t.Fatalf("ParseVersion(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		for i := range got.Prerelease {
+			if got.Prerelease[i] != tt.want.Prerelease[i] {
+				t.Fatalf("ParseVersion(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCompareVersionsPrecedence(t *testing.T) {
+	ordered := []string{
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.0.1",
+		"v1.10.0",
+	}
+
+	for i := 0; i+1 < len(ordered); i++ {
+		if got := CompareVersions(ordered[i], ordered[i+1]); got != -1 {
+			t.Fatalf("CompareVersions(%q, %q) = %d, want -1", ordered[i], ordered[i+1], got)
+		}
+		if got := CompareVersions(ordered[i+1], ordered[i]); got != 1 {
+			t.Fatalf("CompareVersions(%q, %q) = %d, want 1", ordered[i+1], ordered[i], got)
+		}
+	}
+
+	if got := CompareVersions("v1.0.0+build.1", "1.0.0+build.2"); got != 0 {
+		t.Fatalf("CompareVersions() with build metadata = %d, want 0", got)
+	}
+}